@@ -8,23 +8,79 @@ import (
 	"github.com/aaron-vaz/github-branch-bot/pkg/github"
 	"github.com/aaron-vaz/github-branch-bot/pkg/notification"
 	"github.com/aaron-vaz/github-branch-bot/pkg/service"
+	"github.com/aaron-vaz/github-branch-bot/pkg/state"
 	"github.com/aws/aws-lambda-go/lambda"
 )
 
+// appTokens is kept between warm invocations so the github app installation token is reused until it expires
+var appTokens *github.AppTokenSource
+
+// stateStore is kept between warm invocations so the memory store keeps its state
+var stateStore state.Store
+
 // HandleRequest is the main entry point to the application, it will be executed by the AWS
-func HandleRequest() {
-	params := config.ParseParams()
+// the branch check is not run when the configuration is invalid, the returned error lists every problem found
+func HandleRequest() error {
+	params, err := config.ParseParams(config.WebhookURLSetting)
+	if err != nil {
+		return err
+	}
+
 	githubAPI := &github.APIService{BaseURL: params.GithubBaseURL, Token: params.GithubToken, Client: http.DefaultClient}
-	slackAPI := &notification.SlackService{Client: http.DefaultClient}
+	if params.GithubAppID != "" {
+		if appTokens == nil {
+			appTokens, err = github.NewAppTokenSource(params.GithubBaseURL, params.GithubAppID, []byte(params.GithubAppPrivateKey),
+				params.GithubOrganization, int64(params.GithubAppInstallationID), http.DefaultClient)
+			if err != nil {
+				return err
+			}
+		}
+		githubAPI.Tokens = appTokens
+	}
+
+	templates, err := notification.NewTemplates(params.Templates.Summary, params.Templates.Repo, params.Templates.Branch, params.Templates.UpToDate)
+	if err != nil {
+		return err
+	}
+
+	slackAPI := &notification.SlackService{
+		Client:    http.DefaultClient,
+		Users:     &notification.UserResolver{BaseURL: params.SlackAPIURL, Token: params.SlackBotToken, Overrides: params.SlackUsers, Client: http.DefaultClient},
+		Templates: templates,
+		BaseURL:   params.SlackAPIURL,
+		Token:     params.SlackBotToken,
+		Channel:   params.SlackChannel,
+	}
+
+	var store state.Store
+	if params.StateStore != "" {
+		if stateStore == nil {
+			if stateStore, err = state.Open(params.StateStore); err != nil {
+				return err
+			}
+		}
+		store = stateStore
+	}
 
 	branchService := &service.BranchService{
 		Params: params,
 		API:    githubAPI,
 		Msg:    slackAPI,
 		Wg:     &sync.WaitGroup{},
+		State:  store,
+	}
+
+	// teams with their own webhook are notified in their own channel
+	for _, report := range branchService.GenerateReports() {
+		webhookURL := params.WebhookURL
+		if report.WebhookURL != "" {
+			webhookURL = report.WebhookURL
+		}
+
+		slackAPI.Send(webhookURL, report)
 	}
 
-	slackAPI.Notify(params.WebhookURL, branchService.GenerateStatusMessage())
+	return nil
 }
 
 func main() {
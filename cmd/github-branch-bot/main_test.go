@@ -67,7 +67,25 @@ func TestHandleRequest(t *testing.T) {
 		os.Setenv("HEAD_BRANCH_PREFIX", "release")
 		os.Setenv("WEBHOOK_URL", server.URL)
 
-		HandleRequest()
+		if err := HandleRequest(); err != nil {
+			t.Errorf("HandleRequest() unexpected error = %v", err)
+		}
+	}
+}
+
+func TestHandleRequest_InvalidConfig(t *testing.T) {
+	os.Setenv("GITHUB_BASE_URL", "localhost")
+	os.Setenv("GITHUB_TOKEN", "")
+	os.Setenv("GITHUB_ORGANISATION", "org")
+	os.Setenv("WEBHOOK_URL", "")
+
+	want := "invalid configuration:\n" +
+		"  github_token: is required, set GITHUB_TOKEN or github_token in the config document\n" +
+		"  webhook_url: is required, set WEBHOOK_URL or webhook_url in the config document\n" +
+		"  github_base_url: must be an absolute http or https url, got \"localhost\""
+
+	if err := HandleRequest(); err == nil || err.Error() != want {
+		t.Errorf("HandleRequest() error = %v, want %v", err, want)
 	}
 }
 
@@ -2,13 +2,17 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"sync"
+	"unicode"
 
 	"github.com/aaron-vaz/github-branch-bot/pkg/config"
 	"github.com/aaron-vaz/github-branch-bot/pkg/github"
 	"github.com/aaron-vaz/github-branch-bot/pkg/notification"
 	"github.com/aaron-vaz/github-branch-bot/pkg/service"
+	"github.com/aaron-vaz/github-branch-bot/pkg/state"
 	"github.com/aws/aws-lambda-go/lambda"
 )
 
@@ -17,17 +21,69 @@ type Event struct {
 	Query map[string]string `json:"query"`
 }
 
+// appTokens is kept between warm invocations so the github app installation token is reused until it expires
+var appTokens *github.AppTokenSource
+
+// stateStore is kept between warm invocations so the memory store keeps its state
+var stateStore state.Store
+
 // HandleRequest is the main entry point to the application, it will be executed by the AWS
+// the request is rejected when the configuration is invalid, the returned error lists every problem found
 func HandleRequest(request Event) error {
-	params := config.ParseParams()
+	params, err := config.ParseParams(config.SlackCommandTokenSetting)
+	if err != nil {
+		return err
+	}
+
+	// any slack user can run the command, so it never deletes stale branches, at most it reports which would be deleted
+	if params.StaleBranchCleanup == config.CleanupDelete {
+		params.StaleBranchCleanup = config.CleanupDryRun
+	}
+
 	githubAPI := &github.APIService{BaseURL: params.GithubBaseURL, Token: params.GithubToken, Client: http.DefaultClient}
-	slackAPI := &notification.SlackService{Client: http.DefaultClient}
+	if params.GithubAppID != "" {
+		if appTokens == nil {
+			appTokens, err = github.NewAppTokenSource(params.GithubBaseURL, params.GithubAppID, []byte(params.GithubAppPrivateKey),
+				params.GithubOrganization, int64(params.GithubAppInstallationID), http.DefaultClient)
+			if err != nil {
+				return err
+			}
+		}
+		githubAPI.Tokens = appTokens
+	}
+
+	templates, err := notification.NewTemplates(params.Templates.Summary, params.Templates.Repo, params.Templates.Branch, params.Templates.UpToDate)
+	if err != nil {
+		return err
+	}
+
+	slackAPI := &notification.SlackService{
+		Client:    http.DefaultClient,
+		Users:     &notification.UserResolver{BaseURL: params.SlackAPIURL, Token: params.SlackBotToken, Overrides: params.SlackUsers, Client: http.DefaultClient},
+		Templates: templates,
+		BaseURL:   params.SlackAPIURL,
+		Token:     params.SlackBotToken,
+		Channel:   params.SlackChannel,
+	}
+
+	var store state.Store
+	if params.StateStore != "" {
+		if stateStore == nil {
+			if stateStore, err = state.Open(params.StateStore); err != nil {
+				return err
+			}
+		}
+		store = stateStore
+	}
 
 	branchService := &service.BranchService{
 		Params: params,
 		API:    githubAPI,
 		Msg:    slackAPI,
 		Wg:     &sync.WaitGroup{},
+		State:  store,
+		// the command always sends the full report and leaves the state, deltas and escalations to the scheduled runs
+		ReadOnly: true,
 	}
 
 	// first check validation token
@@ -42,12 +98,34 @@ func HandleRequest(request Event) error {
 		return errors.New("No response_url provided")
 	}
 
+	// the command text can list the repos to check as owner/repo
+	// any slack user can run the command, so only repos the bot is configured to check can be listed
+	if repositories := strings.FieldsFunc(request.Query["text"], isRepositorySeparator); len(repositories) > 0 {
+		if unchecked := params.UncheckedRepositories(repositories); len(unchecked) > 0 {
+			errorMsg := fmt.Sprintf("Repositories %s are not checked by this bot, only repos of the configured owners can be listed", strings.Join(unchecked, ", "))
+
+			slackAPI.Notify(responseURL, errorMsg)
+			return errors.New(errorMsg)
+		}
+
+		params.Repositories = repositories
+
+		if err := params.Validate(config.SlackCommandTokenSetting); err != nil {
+			slackAPI.Notify(responseURL, err.Error())
+			return err
+		}
+	}
+
 	// provide response to stop slack command from timing out
 	slackAPI.Notify(responseURL, "Processing request...")
 
 	// do branch check
-	if message := branchService.GenerateStatusMessage(); message != "" {
-		slackAPI.Notify(responseURL, message)
+	if reports := branchService.GenerateReports(); len(reports) > 0 {
+		for _, report := range reports {
+			// command responses are sent to the response url, so details are sent as an attachment rather than a thread
+			report.Thread = false
+			slackAPI.Send(responseURL, report)
+		}
 		return nil
 	}
 
@@ -57,6 +135,10 @@ func HandleRequest(request Event) error {
 	return errors.New(errorMsg)
 }
 
+func isRepositorySeparator(r rune) bool {
+	return unicode.IsSpace(r) || r == ','
+}
+
 func main() {
 	lambda.Start(HandleRequest)
 }
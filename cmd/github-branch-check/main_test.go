@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -96,6 +97,150 @@ func TestHandleRequest(t *testing.T) {
 	}
 }
 
+func TestHandleRequest_Repositories(t *testing.T) {
+	var mu sync.Mutex
+	var requested []string
+	var messages []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if req.Method == http.MethodPost {
+			body, _ := ioutil.ReadAll(req.Body)
+			messages = append(messages, string(body))
+			return
+		}
+
+		requested = append(requested, req.URL.Path)
+		if strings.Contains(req.URL.Path, "branches") {
+			rw.Write(readTestResource("branches-happy-path.json"))
+
+		} else if strings.Contains(req.URL.Path, "compare") {
+			rw.Write(readTestResource("ahead-happy-path.json"))
+
+		} else {
+			rw.Write(readTestResource("repo-happy-path.json"))
+		}
+	}))
+	defer server.Close()
+
+	os.Setenv("GITHUB_BASE_URL", server.URL)
+	os.Setenv("GITHUB_TOKEN", "token")
+	os.Setenv("GITHUB_ORGANISATION", "org")
+	os.Setenv("BASE_BRANCH", "develop")
+	os.Setenv("HEAD_BRANCH_PREFIX", "release")
+	os.Setenv("SLACK_COMMAND_TOKEN", "token")
+
+	err := HandleRequest(Event{Query: map[string]string{"token": "token", "response_url": server.URL, "text": " org/test "}})
+	if err != nil {
+		t.Errorf("HandleRequest() unexpected error = %v", err)
+	}
+
+	for _, path := range requested {
+		if strings.HasPrefix(path, "/orgs/") {
+			t.Errorf("HandleRequest() listed the repos of the org, requested %s", path)
+		}
+	}
+
+	want := `{"text":"*org branch check summary:*\n\n*test*:\nrelease is ahead of develop by 1 commits\n\n"}`
+	if len(messages) != 2 || messages[1] != want {
+		t.Errorf("HandleRequest() sent %v, want %s", messages, want)
+	}
+
+	err = HandleRequest(Event{Query: map[string]string{"token": "token", "response_url": server.URL, "text": "org/test, test"}})
+	if err == nil || !strings.Contains(err.Error(), `repositories[1]: must be written as owner/repo, got "test"`) {
+		t.Errorf("HandleRequest() error = %v, want an invalid repositories error", err)
+	}
+
+	// repos outside the configured org cannot be checked by any slack user
+	messages = nil
+	err = HandleRequest(Event{Query: map[string]string{"token": "token", "response_url": server.URL, "text": "org/test octocat/private"}})
+	if err == nil || !strings.Contains(err.Error(), "octocat/private") {
+		t.Errorf("HandleRequest() error = %v, want a repositories not checked error", err)
+	}
+
+	want = `{"text":"Repositories octocat/private are not checked by this bot, only repos of the configured owners can be listed"}`
+	if len(messages) != 1 || messages[0] != want {
+		t.Errorf("HandleRequest() sent %v, want %s", messages, want)
+	}
+}
+
+func TestHandleRequest_NeverDeletes(t *testing.T) {
+	var mu sync.Mutex
+	var messages []string
+	deleted := false
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if req.Method == http.MethodDelete {
+			deleted = true
+			rw.WriteHeader(http.StatusNoContent)
+
+		} else if req.Method == http.MethodPost {
+			body, _ := ioutil.ReadAll(req.Body)
+			messages = append(messages, string(body))
+
+		} else if strings.Contains(req.URL.Path, "orgs") {
+			rw.Write(readTestResource("repos-happy-path.json"))
+
+		} else if strings.Contains(req.URL.Path, "branches/") {
+			rw.Write(readTestResource("branch-unprotected.json"))
+
+		} else if strings.Contains(req.URL.Path, "branches") {
+			rw.Write(readTestResource("branches-happy-path.json"))
+
+		} else if strings.Contains(req.URL.Path, "compare") {
+			rw.Write(readTestResource("ahead-happy-path.json"))
+
+		} else if strings.Contains(req.URL.Path, "pulls") {
+			rw.Write([]byte("[]"))
+		}
+	}))
+	defer server.Close()
+
+	os.Setenv("GITHUB_BASE_URL", server.URL)
+	os.Setenv("GITHUB_TOKEN", "token")
+	os.Setenv("GITHUB_ORGANISATION", "org")
+	os.Setenv("BASE_BRANCH", "develop")
+	os.Setenv("HEAD_BRANCH_PREFIX", "release")
+	os.Setenv("SLACK_COMMAND_TOKEN", "token")
+	os.Setenv("STALE_BRANCH_DAYS", "30")
+	os.Setenv("STALE_BRANCH_CLEANUP", "delete")
+	defer os.Setenv("STALE_BRANCH_DAYS", "")
+	defer os.Setenv("STALE_BRANCH_CLEANUP", "")
+
+	if err := HandleRequest(Event{Query: map[string]string{"token": "token", "response_url": server.URL}}); err != nil {
+		t.Errorf("HandleRequest() unexpected error = %v", err)
+	}
+
+	if deleted {
+		t.Error("HandleRequest() deleted a stale branch")
+	}
+
+	if len(messages) != 2 || !strings.Contains(messages[1], "would delete stale branch master") {
+		t.Errorf("HandleRequest() sent %v, want a dry run deletion message", messages)
+	}
+}
+
+func TestHandleRequest_InvalidConfig(t *testing.T) {
+	os.Setenv("GITHUB_BASE_URL", "http://localhost.com")
+	os.Setenv("GITHUB_TOKEN", "token")
+	os.Setenv("GITHUB_ORGANISATION", "")
+	os.Setenv("SLACK_COMMAND_TOKEN", "")
+
+	want := "invalid configuration:\n" +
+		"  github_organisation: is required, set GITHUB_ORGANISATION or github_organisation in the config document\n" +
+		"  slack_command_token: is required, set SLACK_COMMAND_TOKEN or slack_command_token in the config document"
+
+	err := HandleRequest(Event{Query: map[string]string{"token": "", "response_url": "http://localhost.com"}})
+	if err == nil || err.Error() != want {
+		t.Errorf("HandleRequest() error = %v, want %v", err, want)
+	}
+}
+
 func readTestResource(path string) []byte {
 	content, err := ioutil.ReadFile(filepath.Join("test-resources", path))
 	if err != nil {
@@ -3,8 +3,10 @@ module github.com/aaron-vaz/github-branch-bot
 require (
 	github.com/aaron-vaz/golang-utils v0.0.0-20190212180741-6adf6fc8d987
 	github.com/aws/aws-lambda-go v1.8.1
+	github.com/aws/aws-sdk-go v1.42.1
 	github.com/google/go-cmp v0.2.0
 	github.com/stretchr/testify v1.3.0 // indirect
+	gopkg.in/yaml.v2 v2.4.0
 )
 
 go 1.13
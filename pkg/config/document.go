@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/aaron-vaz/golang-utils/pkg/ioutils"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gopkg.in/yaml.v2"
+)
+
+// SchemaVersion is the version of the configuration document supported by this release
+const SchemaVersion = 1
+
+const s3Scheme = "s3://"
+
+// getS3Object fetches the supplied object from s3, it is a variable so tests can replace it
+var getS3Object = readS3Object
+
+// loadDocument reads the configuration document, if any, into the params
+// the document is read from CONFIG_DOCUMENT when it holds the whole document, otherwise from the path or s3:// url in CONFIG_FILE
+// documents can be written in yaml or json
+func (p *Params) loadDocument() error {
+	document := []byte(getEnv("CONFIG_DOCUMENT", ""))
+
+	if location := getEnv("CONFIG_FILE", ""); len(document) == 0 && location != "" {
+		var err error
+		if document, err = readDocument(location); err != nil {
+			return fmt.Errorf("could not read config file %s: %v", location, err)
+		}
+	}
+
+	if len(document) == 0 {
+		return nil
+	}
+
+	if err := yaml.UnmarshalStrict(document, p); err != nil {
+		return fmt.Errorf("could not parse config document: %v", err)
+	}
+
+	if p.Version == 0 {
+		return &ValidationError{Problems: []FieldError{{Field: "version", Message: fmt.Sprintf("is required, the current schema version is %d", SchemaVersion)}}}
+	}
+
+	// head branch prefixes in the document can override the base branch in the same way as the environment variable
+	var overrides map[string]string
+	p.HeadBranchPrefixes, overrides = splitBaseOverrides(p.HeadBranchPrefixes)
+	for pattern, base := range overrides {
+		if p.BaseOverrides == nil {
+			p.BaseOverrides = make(map[string]string)
+		}
+		p.BaseOverrides[pattern] = base
+	}
+
+	return nil
+}
+
+func readDocument(location string) ([]byte, error) {
+	if !strings.HasPrefix(location, s3Scheme) {
+		return ioutil.ReadFile(location)
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(location, s3Scheme), "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("s3 locations must be of the form s3://bucket/key")
+	}
+
+	return getS3Object(parts[0], parts[1])
+}
+
+func readS3Object(bucket, key string) ([]byte, error) {
+	sess, err := session.NewSession()
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := s3.New(sess).GetObject(&s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		return nil, err
+	}
+
+	defer ioutils.Close(output.Body)
+
+	return ioutil.ReadAll(output.Body)
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testResources = "test-resources"
+
+func TestParseParams_Document(t *testing.T) {
+	fileParams := &Params{
+		Version:              1,
+		GithubBaseURL:        "https://github.example.com/api/v3",
+		GithubToken:          "file-token",
+		GithubOrganization:   "file-org",
+		BaseBranch:           "main",
+		HeadBranchPrefixes:   []string{"release/*", "hotfix/**"},
+		BaseOverrides:        map[string]string{"release/*": "main"},
+		WebhookURL:           "https://hooks.slack.com/services/T000/B000/XXX",
+		BaseBranchMode:       BaseModeRepo,
+		BaseBranchCandidates: []string{"develop"},
+		StaleBranchDays:      30,
+		StaleBranchCleanup:   CleanupOff,
+		ReportMode:           ReportCombined,
+		NotifyMode:           NotifyFull,
+		DeltaThreshold:       1,
+		DigestDay:            "monday",
+		BranchRules: []Rule{
+			{Name: "release", Base: "main", Head: []string{"release/*"}, Threshold: 2, Severity: &SeverityThresholds{Critical: Threshold{Ahead: 5}}},
+			{Name: "main", Base: "develop", Head: []string{"main"}, Template: "{{.Head}} needs merging into {{.Base}}"},
+		},
+		Severity: Severity{
+			SeverityThresholds: SeverityThresholds{Warning: Threshold{Ahead: 10, Days: 7}, Critical: Threshold{Ahead: 50, Days: 30}},
+			Repos:              map[string]SeverityThresholds{"file-org/legacy": {Warning: Threshold{Days: 60}}},
+			Minimum:            SeverityWarning,
+			Emoji:              map[string]string{SeverityCritical: ":fire:"},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		envSupplier func()
+		want        *Params
+	}{
+		{
+			name: "Test yaml file path",
+			envSupplier: func() {
+				os.Setenv("CONFIG_FILE", filepath.Join(testResources, "config.yaml"))
+			},
+			want: fileParams,
+		},
+
+		{
+			name: "Test s3 file path",
+			envSupplier: func() {
+				os.Setenv("CONFIG_FILE", "s3://bucket/branch-bot/config.yaml")
+			},
+			want: fileParams,
+		},
+
+		{
+			name: "Test json document path",
+			envSupplier: func() {
+				os.Setenv("CONFIG_DOCUMENT", string(readTestResource("config.json")))
+			},
+			want: &Params{
+				Version:                1,
+				GithubBaseURL:          DefaultGithubBaseURL,
+				GithubToken:            "file-token",
+				GithubOrganization:     "file-org",
+				BaseBranch:             "develop",
+				HeadBranchPrefixes:     []string{"master"},
+				BaseBranchMode:         BaseModeFixed,
+				IgnoreOpenPullRequests: true,
+				StaleBranchCleanup:     CleanupOff,
+				ReportMode:             ReportCombined,
+				NotifyMode:             NotifyFull,
+				DeltaThreshold:         1,
+				DigestDay:              "monday",
+			},
+		},
+
+		{
+			name: "Test environment overrides path",
+			envSupplier: func() {
+				os.Setenv("CONFIG_DOCUMENT", string(readTestResource("config.json")))
+				os.Setenv("CONFIG_FILE", filepath.Join(testResources, "config.yaml"))
+				os.Setenv("GITHUB_TOKEN", "env-token")
+				os.Setenv("HEAD_BRANCH_PREFIX", "release=main")
+				os.Setenv("IGNORE_OPEN_PULL_REQUESTS", "false")
+			},
+			want: &Params{
+				Version:            1,
+				GithubBaseURL:      DefaultGithubBaseURL,
+				GithubToken:        "env-token",
+				GithubOrganization: "file-org",
+				BaseBranch:         "develop",
+				HeadBranchPrefixes: []string{"release"},
+				BaseOverrides:      map[string]string{"release": "main"},
+				BaseBranchMode:     BaseModeFixed,
+				StaleBranchCleanup: CleanupOff,
+				ReportMode:         ReportCombined,
+				NotifyMode:         NotifyFull,
+				DeltaThreshold:     1,
+				DigestDay:          "monday",
+			},
+		},
+	}
+
+	getS3Object = func(bucket, key string) ([]byte, error) {
+		if bucket != "bucket" || key != "branch-bot/config.yaml" {
+			return nil, errors.New("NoSuchKey")
+		}
+		return readTestResource("config.yaml"), nil
+	}
+	defer func() { getS3Object = readS3Object }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.envSupplier()
+
+			got, err := ParseParams()
+			if err != nil {
+				t.Errorf("ParseParams() unexpected error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseParams() = %+v, want %+v", got, tt.want)
+			}
+
+			clearEnvs()
+		})
+	}
+}
+
+func TestParams_loadDocument(t *testing.T) {
+	tests := []struct {
+		name     string
+		location string
+		wantErr  string
+	}{
+		{
+			name:     "Test missing file path",
+			location: filepath.Join(testResources, "missing.yaml"),
+			wantErr:  "could not read config file test-resources/missing.yaml: open test-resources/missing.yaml: no such file or directory",
+		},
+		{
+			name:     "Test invalid s3 location path",
+			location: "s3://bucket",
+			wantErr:  "could not read config file s3://bucket: s3 locations must be of the form s3://bucket/key",
+		},
+		{
+			name:     "Test unknown field path",
+			location: filepath.Join(testResources, "unknown-field.yaml"),
+			wantErr:  "could not parse config document: yaml: unmarshal errors:\n  line 2: field github_tokn not found in type config.Params",
+		},
+		{
+			name:     "Test missing version path",
+			location: filepath.Join(testResources, "no-version.yaml"),
+			wantErr:  "invalid configuration:\n  version: is required, the current schema version is 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("CONFIG_FILE", tt.location)
+			defer clearEnvs()
+
+			err := (&Params{}).loadDocument()
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Params.loadDocument() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func readTestResource(path string) []byte {
+	content, err := ioutil.ReadFile(filepath.Join(testResources, path))
+	if err != nil {
+		panic(err)
+	}
+
+	return content
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Escalation is what is done once a branch has been ahead of its base branch for a number of days
+// escalations are levels in ascending order of days, each level fires once for as long as the branch stays ahead
+type Escalation struct {
+	// Days is how many days a branch has to be ahead for the escalation to fire
+	Days int `json:"days" yaml:"days"`
+	// Mention mentions the people responsible for the branch in the report, using the authors of its commits when no mentions are configured
+	Mention bool `json:"mention" yaml:"mention"`
+	// WebhookURL posts the escalation to another channel
+	WebhookURL string `json:"webhook_url" yaml:"webhook_url"`
+	// Issue opens a github issue in the repo describing the branch
+	Issue bool `json:"issue" yaml:"issue"`
+	// Labels are added to the issue opened by the escalation
+	Labels []string `json:"labels" yaml:"labels"`
+}
+
+// EscalationLevel returns the highest escalation level reached by a branch that has been ahead for the supplied number of days
+// levels start at 1, it is 0 when no escalation is reached
+func (p *Params) EscalationLevel(days int) int {
+	level := 0
+	for i, escalation := range p.Escalations {
+		if days >= escalation.Days {
+			level = i + 1
+		}
+	}
+	return level
+}
+
+func (p *Params) validateEscalations(errs *ValidationError) {
+	if len(p.Escalations) > 0 && p.StateStore == "" {
+		errs.add("escalations", "need state_store to be set")
+	}
+
+	for i, escalation := range p.Escalations {
+		path := fmt.Sprintf("escalations[%d]", i)
+
+		if escalation.Days < 1 {
+			errs.add(path+".days", "must be at least 1, got %d", escalation.Days)
+		} else if i > 0 && escalation.Days <= p.Escalations[i-1].Days {
+			errs.add(path+".days", "must be more than the days of the previous escalation, got %d", escalation.Days)
+		}
+
+		if !escalation.Mention && !escalation.Issue && strings.TrimSpace(escalation.WebhookURL) == "" {
+			errs.add(path, "must mention, post to a webhook_url or open an issue")
+		}
+
+		if escalation.WebhookURL != "" {
+			validateURL(errs, path+".webhook_url", escalation.WebhookURL)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Mention sources
+const (
+	// MentionCodeOwners mentions the CODEOWNERS of the files changed on a branch that is ahead
+	MentionCodeOwners = "codeowners"
+	// MentionAuthors mentions the authors of the commits a branch is ahead by
+	MentionAuthors = "authors"
+)
+
+// MentionsFrom reports whether the supplied mention source is enabled
+func (p *Params) MentionsFrom(source string) bool {
+	return oneOf(source, p.Mentions...)
+}
+
+// parseSlackUsers parses a list of github logins mapped to slack ids written as login=id
+func parseSlackUsers(values []string) map[string]string {
+	users := make(map[string]string, len(values))
+	for _, value := range values {
+		login, id := strings.TrimSpace(value), ""
+		if i := strings.Index(value, "="); i >= 0 {
+			login, id = strings.TrimSpace(value[:i]), strings.TrimSpace(value[i+1:])
+		}
+
+		users[login] = id
+	}
+
+	return users
+}
+
+func (p *Params) validateMentions(errs *ValidationError) {
+	for i, source := range p.Mentions {
+		if !oneOf(source, MentionCodeOwners, MentionAuthors) {
+			errs.add(fmt.Sprintf("mentions[%d]", i), "must be one of %s or %s, got %q", MentionCodeOwners, MentionAuthors, source)
+		}
+	}
+
+	logins := make([]string, 0, len(p.SlackUsers))
+	for login := range p.SlackUsers {
+		logins = append(logins, login)
+	}
+	sort.Strings(logins)
+
+	for _, login := range logins {
+		if strings.TrimSpace(p.SlackUsers[login]) == "" {
+			errs.add(fmt.Sprintf("slack_users[%q]", login), "must name a slack id, use login=id")
+		}
+	}
+
+	if p.SlackAPIURL != "" {
+		validateURL(errs, "slack_api_url", p.SlackAPIURL)
+	}
+}
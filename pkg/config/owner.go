@@ -0,0 +1,211 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Owner types
+const (
+	OwnerOrg  = "org"
+	OwnerUser = "user"
+)
+
+// Report modes
+const (
+	// ReportCombined sends a single report covering every owner
+	ReportCombined = "combined"
+	// ReportPerOwner sends a separate report for each owner
+	ReportPerOwner = "per-owner"
+)
+
+// Owner is an organisation or user account whose repos are checked
+type Owner struct {
+	Name string `json:"name" yaml:"name"`
+	// Type is one of org or user, defaults to org
+	Type string `json:"type" yaml:"type"`
+	// Token authenticates the requests for the repos of this owner instead of the github token or app
+	Token string `json:"token" yaml:"token"`
+	// Rules replace the branch rules of the params for the repos of this owner
+	Rules []Rule `json:"rules" yaml:"rules"`
+	// Repositories are the names of the only repos of this owner that are checked, when empty every repo of the owner is checked
+	Repositories []string `json:"repositories" yaml:"repositories"`
+	// Teams checks the repos of these org teams instead of every repo of the org, the report is grouped by team
+	Teams []Team `json:"teams" yaml:"teams"`
+}
+
+// Team is an org team whose repos are checked
+type Team struct {
+	Slug string `json:"slug" yaml:"slug"`
+	// WebhookURL sends the report of the team to its own channel instead of including it in the main report
+	WebhookURL string `json:"webhook_url" yaml:"webhook_url"`
+}
+
+// IsUser reports whether the owner is a user account rather than an organisation
+func (o *Owner) IsUser() bool {
+	return o.Type == OwnerUser
+}
+
+// Owners returns the configured owners
+// when none are configured the github organisation is the only owner
+// when repositories are listed the owners of those repos are returned instead, keeping the settings of the configured owners but their teams
+func (p *Params) Owners() []Owner {
+	if len(p.Repositories) > 0 {
+		return p.repositoryOwners()
+	}
+
+	if len(p.GithubOwners) > 0 {
+		return p.GithubOwners
+	}
+
+	return []Owner{{Name: p.GithubOrganization, Type: OwnerOrg, Teams: p.Teams}}
+}
+
+// repositoryOwners groups the listed repositories by owner in the order they are listed
+func (p *Params) repositoryOwners() []Owner {
+	var owners []Owner
+	index := make(map[string]int)
+
+	for _, repository := range p.Repositories {
+		name, repo, ok := splitRepository(repository)
+		if !ok {
+			continue
+		}
+
+		i, seen := index[name]
+		if !seen {
+			owner := Owner{Name: name, Type: OwnerOrg}
+			for _, configured := range p.GithubOwners {
+				if configured.Name == name {
+					owner = configured
+				}
+			}
+
+			// the listed repos are checked on their own, so they are not checked again for each team of the owner
+			owner.Repositories, owner.Teams = nil, nil
+			i = len(owners)
+			index[name] = i
+			owners = append(owners, owner)
+		}
+
+		owners[i].Repositories = append(owners[i].Repositories, repo)
+	}
+
+	return owners
+}
+
+// UncheckedRepositories returns the supplied repos, written as owner/repo, that the configuration does not check
+// a repo is checked when it belongs to the github organisation or one of the owners, or when it is one of the listed repositories
+func (p *Params) UncheckedRepositories(repositories []string) []string {
+	var unchecked []string
+	for _, repository := range repositories {
+		name, _, ok := splitRepository(repository)
+		if !ok || p.checksRepository(name, repository) {
+			continue
+		}
+
+		unchecked = append(unchecked, strings.TrimSpace(repository))
+	}
+
+	return unchecked
+}
+
+// checksRepository reports whether the supplied repo of the supplied owner is checked by the configuration
+func (p *Params) checksRepository(owner, repository string) bool {
+	if strings.EqualFold(owner, p.GithubOrganization) {
+		return true
+	}
+
+	for _, configured := range p.GithubOwners {
+		if strings.EqualFold(owner, configured.Name) {
+			return true
+		}
+	}
+
+	for _, configured := range p.Repositories {
+		if strings.EqualFold(strings.TrimSpace(repository), strings.TrimSpace(configured)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitRepository splits a repo written as owner/repo
+func splitRepository(repository string) (string, string, bool) {
+	parts := strings.Split(strings.TrimSpace(repository), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// RulesFor returns the branch rules used for the repos of the supplied owner
+func (p *Params) RulesFor(owner Owner) []Rule {
+	if len(owner.Rules) > 0 {
+		return owner.Rules
+	}
+	return p.Rules()
+}
+
+// parseTeams parses a list of teams written as slug or slug=webhook url
+func parseTeams(values []string) []Team {
+	teams := make([]Team, 0, len(values))
+	for _, value := range values {
+		team := Team{Slug: strings.TrimSpace(value)}
+		if i := strings.Index(value, "="); i >= 0 {
+			team.Slug, team.WebhookURL = strings.TrimSpace(value[:i]), strings.TrimSpace(value[i+1:])
+		}
+
+		teams = append(teams, team)
+	}
+
+	return teams
+}
+
+// parseOwners parses a list of owners written as name, org:name or user:name
+func parseOwners(values []string) []Owner {
+	owners := make([]Owner, 0, len(values))
+	for _, value := range values {
+		owner := Owner{Name: strings.TrimSpace(value), Type: OwnerOrg}
+		if i := strings.Index(owner.Name, ":"); i >= 0 {
+			owner.Type, owner.Name = strings.TrimSpace(owner.Name[:i]), strings.TrimSpace(owner.Name[i+1:])
+		}
+
+		owners = append(owners, owner)
+	}
+
+	return owners
+}
+
+func (o *Owner) validate(errs *ValidationError, path string) {
+	if strings.TrimSpace(o.Name) == "" {
+		errs.add(path+".name", "is required")
+	}
+
+	if o.Type != "" && !oneOf(o.Type, OwnerOrg, OwnerUser) {
+		errs.add(path+".type", "must be one of %s or %s, got %q", OwnerOrg, OwnerUser, o.Type)
+	}
+
+	for i, rule := range o.Rules {
+		rule.validate(errs, fmt.Sprintf("%s.rules[%d]", path, i))
+	}
+
+	if len(o.Teams) > 0 && o.IsUser() {
+		errs.add(path+".teams", "only organisations have teams")
+	}
+
+	validateTeams(errs, path+".teams", o.Teams)
+}
+
+func validateTeams(errs *ValidationError, path string, teams []Team) {
+	for i, team := range teams {
+		if strings.TrimSpace(team.Slug) == "" {
+			errs.add(fmt.Sprintf("%s[%d].slug", path, i), "is required")
+		}
+
+		if team.WebhookURL != "" {
+			validateURL(errs, fmt.Sprintf("%s[%d].webhook_url", path, i), team.WebhookURL)
+		}
+	}
+}
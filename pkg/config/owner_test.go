@@ -0,0 +1,112 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParams_UncheckedRepositories(t *testing.T) {
+	tests := []struct {
+		name         string
+		params       *Params
+		repositories []string
+		want         []string
+	}{
+		{
+			name:         "Test organisation path",
+			params:       &Params{GithubOrganization: "org"},
+			repositories: []string{"org/api", "Org/web", "octocat/test"},
+			want:         []string{"octocat/test"},
+		},
+		{
+			name:         "Test owners path",
+			params:       &Params{GithubOwners: []Owner{{Name: "org"}, {Name: "someone", Type: OwnerUser}}},
+			repositories: []string{"someone/dotfiles", "org/api", "other/api"},
+			want:         []string{"other/api"},
+		},
+		{
+			name:         "Test listed repositories path",
+			params:       &Params{Repositories: []string{"octocat/test"}},
+			repositories: []string{"octocat/test", "octocat/private"},
+			want:         []string{"octocat/private"},
+		},
+		{
+			name:         "Test invalid repositories path",
+			params:       &Params{GithubOrganization: "org"},
+			repositories: []string{"test"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.params.UncheckedRepositories(tt.repositories); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Params.UncheckedRepositories() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParams_Owners(t *testing.T) {
+	release := Rule{Name: "release", Head: []string{"release/*"}}
+	hotfix := Rule{Name: "hotfix", Head: []string{"hotfix/*"}}
+
+	tests := []struct {
+		name      string
+		params    *Params
+		want      []Owner
+		wantRules [][]Rule
+	}{
+		{
+			name:      "Test organisation path",
+			params:    &Params{GithubOrganization: "org", BranchRules: []Rule{release}},
+			want:      []Owner{{Name: "org", Type: OwnerOrg}},
+			wantRules: [][]Rule{{release}},
+		},
+		{
+			name:      "Test teams path",
+			params:    &Params{GithubOrganization: "org", BranchRules: []Rule{release}, Teams: []Team{{Slug: "payments"}}},
+			want:      []Owner{{Name: "org", Type: OwnerOrg, Teams: []Team{{Slug: "payments"}}}},
+			wantRules: [][]Rule{{release}},
+		},
+		{
+			name: "Test owners path",
+			params: &Params{
+				GithubOrganization: "ignored",
+				BranchRules:        []Rule{release},
+				GithubOwners:       []Owner{{Name: "org"}, {Name: "someone", Type: OwnerUser, Rules: []Rule{hotfix}}},
+			},
+			want:      []Owner{{Name: "org"}, {Name: "someone", Type: OwnerUser, Rules: []Rule{hotfix}}},
+			wantRules: [][]Rule{{release}, {hotfix}},
+		},
+		{
+			name: "Test repositories path",
+			params: &Params{
+				GithubOrganization: "ignored",
+				BranchRules:        []Rule{release},
+				GithubOwners: []Owner{
+					{Name: "someone", Type: OwnerUser, Token: "user-token", Rules: []Rule{hotfix}, Repositories: []string{"ignored"}},
+					{Name: "org", Teams: []Team{{Slug: "a"}, {Slug: "b"}}},
+				},
+				Repositories: []string{"org/api", "someone/dotfiles", "org/web", "invalid"},
+			},
+			want: []Owner{
+				{Name: "org", Repositories: []string{"api", "web"}},
+				{Name: "someone", Type: OwnerUser, Token: "user-token", Rules: []Rule{hotfix}, Repositories: []string{"dotfiles"}},
+			},
+			wantRules: [][]Rule{{release}, {hotfix}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.params.Owners()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Params.Owners() = %v, want %v", got, tt.want)
+			}
+
+			for i, owner := range got {
+				if rules := tt.params.RulesFor(owner); !reflect.DeepEqual(rules, tt.wantRules[i]) {
+					t.Errorf("Params.RulesFor(%s) = %v, want %v", owner.Name, rules, tt.wantRules[i])
+				}
+			}
+		})
+	}
+}
@@ -2,35 +2,372 @@ package config
 
 import (
 	"os"
+	"strconv"
 	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Stale branch cleanup modes
+const (
+	CleanupOff    = "off"
+	CleanupDryRun = "dry-run"
+	CleanupDelete = "delete"
+)
+
+// Base branch modes
+const (
+	// BaseModeFixed compares every repo against BaseBranch and skips repos with a different default branch
+	BaseModeFixed = "fixed"
+	// BaseModeRepo compares each repo against the first of BaseBranchCandidates it contains, otherwise its default branch
+	BaseModeRepo = "repo"
+)
+
+// Notify modes
+const (
+	// NotifyFull reports every branch that is ahead on every run
+	NotifyFull = "full"
+	// NotifyDelta only reports the branches that became ahead, became up to date or grew since the last run, a full digest is sent weekly
+	NotifyDelta = "delta"
+)
+
+// Up to date modes
+const (
+	// UpToDateList lists every repo that is up to date in the summary
+	UpToDateList = "list"
+	// UpToDateOmit leaves the repos that are up to date out of the summary, it ends with how many there are
+	UpToDateOmit = "omit"
+	// UpToDateThread leaves the repos that are up to date out of the summary and lists them in a thread reply
+	UpToDateThread = "thread"
+	// UpToDateAttachment leaves the repos that are up to date out of the summary and lists them in a collapsed attachment
+	UpToDateAttachment = "attachment"
 )
 
 // Params represents the configuration params that will be used by the services
+// they can be loaded from a yaml or json document, environment variables override individual settings of the document
 type Params struct {
-	GithubBaseURL      string
-	GithubToken        string
-	GithubOrganization string
-	BaseBranch         string
-	HeadBranchPrefixes []string
-	WebhookURL         string
-	SlackCommandToken  string
-}
-
-// ParseParams read the configuration parameters from environment variables and creates a Params struct to return
-func ParseParams() *Params {
-	return &Params{
-		GithubBaseURL:      getEnv("GITHUB_BASE_URL", "http://localhost.com"),
-		GithubToken:        getEnv("GITHUB_TOKEN", ""),
-		GithubOrganization: getEnv("GITHUB_ORGANISATION", ""),
-		BaseBranch:         getEnv("BASE_BRANCH", "develop"),
-		HeadBranchPrefixes: splitEnv("HEAD_BRANCH_PREFIX", "master", ","),
-		WebhookURL:         getEnv("WEBHOOK_URL", "http://localhost.com"),
-		SlackCommandToken:  getEnv("SLACK_COMMAND_TOKEN", ""),
-	}
-}
-
-func splitEnv(key, fallback, delimeter string) []string {
-	return strings.Split(getEnv(key, fallback), delimeter)
+	// Version is the schema version of the configuration document
+	Version int `yaml:"version"`
+
+	GithubBaseURL      string   `yaml:"github_base_url"`
+	GithubToken        string   `yaml:"github_token"`
+	GithubOrganization string   `yaml:"github_organisation"`
+	BaseBranch         string   `yaml:"base_branch"`
+	HeadBranchPrefixes []string `yaml:"head_branch_prefixes"`
+	HeadBranchExcludes []string `yaml:"head_branch_excludes"`
+	WebhookURL         string   `yaml:"webhook_url"`
+	SlackCommandToken  string   `yaml:"slack_command_token"`
+
+	// GithubOwners are the organisations and user accounts that are checked, when empty only GithubOrganization is checked
+	GithubOwners []Owner `yaml:"owners"`
+	// ReportMode controls whether a single report is sent for every owner or one report per owner, one of combined or per-owner
+	ReportMode string `yaml:"report_mode"`
+
+	// Teams checks the repos of these teams of GithubOrganization instead of every repo of the org
+	Teams []Team `yaml:"teams"`
+	// Repositories are the only repos that are checked, written as owner/repo, when empty the repos of every owner are listed
+	Repositories []string `yaml:"repositories"`
+	// RepoFilter selects the repos of each owner that are checked
+	RepoFilter RepoFilter `yaml:"repo_filter"`
+
+	// GithubAppID authenticates as a github app installation instead of using GithubToken when set
+	GithubAppID string `yaml:"github_app_id"`
+	// GithubAppPrivateKey is the pem encoded private key of the github app
+	GithubAppPrivateKey string `yaml:"github_app_private_key"`
+	// GithubAppInstallationID is the installation of the app to use, when 0 it is looked up from the organisation
+	GithubAppInstallationID int `yaml:"github_app_installation_id"`
+
+	// BaseOverrides maps a head branch pattern to the base branch its branches are compared against instead of BaseBranch
+	BaseOverrides map[string]string `yaml:"base_overrides"`
+	// BranchRules are the comparisons performed on every repo, when empty a single rule is built from the head branch prefixes
+	BranchRules []Rule `yaml:"rules"`
+	// BaseBranchMode controls how the base branch of each repo is chosen, one of fixed or repo
+	BaseBranchMode string `yaml:"base_branch_mode"`
+	// BaseBranchCandidates are the branches that are preferred over the default branch in repo mode
+	BaseBranchCandidates []string `yaml:"base_branch_candidates"`
+
+	// Mentions are the sources used to find who is responsible for a branch that is ahead, any of codeowners or authors
+	Mentions []string `yaml:"mentions"`
+	// SlackUsers maps github logins, teams and emails to the slack ids mentioned in their place, user group ids start with S
+	// it overrides the slack users looked up by email, people without a slack user are mentioned by login
+	SlackUsers map[string]string `yaml:"slack_users"`
+	// SlackBotToken looks up the slack users of commit and CODEOWNERS emails with users.lookupByEmail when set
+	SlackBotToken string `yaml:"slack_bot_token"`
+	// SlackAPIURL is the url of the slack web api, it defaults to the public slack api
+	SlackAPIURL string `yaml:"slack_api_url"`
+	// SlackChannel is the channel the report is posted to with the bot token when the up to date repos are listed in a thread
+	SlackChannel string `yaml:"slack_channel"`
+
+	// StateStore keeps the branches that are ahead between runs so reports can show how long they have been ahead
+	// it is written as memory, file:/path/to/state.json, s3://bucket/key or dynamodb:table, state is not kept when it is empty
+	StateStore string `yaml:"state_store"`
+
+	// NotifyMode controls whether every branch that is ahead is reported or only the changes since the last run, one of full or delta
+	// delta needs a state store
+	NotifyMode string `yaml:"notify_mode"`
+	// DeltaThreshold is how many commits a branch that is still ahead has to grow by to be reported in delta mode, at least 1
+	DeltaThreshold int `yaml:"delta_threshold"`
+	// DigestDay is the day of the week the full report is sent in delta mode, none never sends it
+	DigestDay string `yaml:"digest_day"`
+
+	// Escalations are the actions taken once a branch has been ahead for a number of days, they need a state store
+	Escalations []Escalation `yaml:"escalations"`
+
+	// UpToDateMode controls how the repos that are up to date are reported, one of list, omit, thread or attachment
+	// only the reports sent to the default webhook can use a thread, the others fall back to an attachment
+	UpToDateMode string `yaml:"up_to_date_mode"`
+
+	// Templates replace the wording of the reports
+	Templates MessageTemplates `yaml:"templates"`
+
+	// Severity classifies the branches that are ahead as info, warning or critical by commit count and age
+	Severity Severity `yaml:"severity"`
+
+	// DriftIssues keeps a github issue open in every repo with branches that are ahead, it is closed once the repo is up to date
+	DriftIssues bool `yaml:"drift_issues"`
+	// DriftIssueLabel labels the drift issues so they can be found on the next run, it defaults to branch-drift
+	DriftIssueLabel string `yaml:"drift_issue_label"`
+
+	// CommitStatus sets a commit status on the head of the base branch of every repo listing the branches that are ahead of it
+	CommitStatus bool `yaml:"commit_status"`
+	// CommitStatusContext names the commit status, it defaults to branch-bot
+	CommitStatusContext string `yaml:"commit_status_context"`
+	// CommitStatusThreshold fails the commit status when a branch is ahead by at least this many commits, 0 never fails it
+	CommitStatusThreshold int `yaml:"commit_status_threshold"`
+
+	// IgnoreOpenPullRequests leaves branches that already have an open pull request into the base branch out of the report
+	IgnoreOpenPullRequests bool `yaml:"ignore_open_pull_requests"`
+
+	// StaleBranchDays reports branches that have had no commits for this many days, 0 disables the check
+	StaleBranchDays int `yaml:"stale_branch_days"`
+	// StaleBranchesAll checks every branch in the repo for staleness, not only the ones matching the head prefixes
+	StaleBranchesAll bool `yaml:"stale_branch_all"`
+	// StaleBranchCleanup controls whether stale branches are deleted, one of off, dry-run or delete
+	StaleBranchCleanup string `yaml:"stale_branch_cleanup"`
+}
+
+// Rules returns the configured branch rules
+// when none are configured a single rule is built from the head branch prefixes and excludes
+func (p *Params) Rules() []Rule {
+	if len(p.BranchRules) > 0 {
+		return p.BranchRules
+	}
+
+	return []Rule{{
+		Head:          p.HeadBranchPrefixes,
+		Exclude:       p.HeadBranchExcludes,
+		BaseOverrides: p.BaseOverrides,
+	}}
+}
+
+// DigestWeekday returns the day of the week the full report is sent in delta mode, ok is false when it is never sent
+func (p *Params) DigestWeekday() (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(p.DigestDay, day.String()) {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+// ListsUpToDate reports whether the repos that are up to date are listed in the summary
+func (p *Params) ListsUpToDate() bool {
+	return p.UpToDateMode == "" || p.UpToDateMode == UpToDateList
+}
+
+// DefaultGithubBaseURL is the url of the public github api
+const DefaultGithubBaseURL = "https://api.github.com"
+
+// Settings that are only needed by some of the handlers, pass them to ParseParams to make them required
+const (
+	WebhookURLSetting        = "webhook_url"
+	SlackCommandTokenSetting = "slack_command_token"
+)
+
+// ParseParams read the configuration parameters from the configuration document and environment variables and creates a Params struct to return
+// secret settings written as references such as ssm:/branch-bot/github-token are resolved using SecretProviders
+// the returned error lists every missing or invalid setting, the required settings are checked on top of the github credentials and organisation
+func ParseParams(required ...string) (*Params, error) {
+	params := &Params{
+		GithubBaseURL:      DefaultGithubBaseURL,
+		BaseBranch:         "develop",
+		HeadBranchPrefixes: []string{"master"},
+		BaseBranchMode:     BaseModeFixed,
+		StaleBranchCleanup: CleanupOff,
+		ReportMode:         ReportCombined,
+		NotifyMode:         NotifyFull,
+		DeltaThreshold:     1,
+		DigestDay:          "monday",
+	}
+
+	if err := params.loadDocument(); err != nil {
+		return nil, err
+	}
+
+	errs := &ValidationError{}
+	params.applyEnv(errs)
+	params.resolveSecrets(errs)
+	params.validate(errs, required)
+
+	if len(errs.Problems) > 0 {
+		return nil, errs
+	}
+
+	return params, nil
+}
+
+// applyEnv overrides the params with the environment variables that are set
+// values that cannot be parsed are added to errs using the name of the environment variable
+func (p *Params) applyEnv(errs *ValidationError) {
+	p.GithubBaseURL = getEnv("GITHUB_BASE_URL", p.GithubBaseURL)
+	p.GithubToken = getEnv("GITHUB_TOKEN", p.GithubToken)
+	p.GithubOrganization = getEnv("GITHUB_ORGANISATION", p.GithubOrganization)
+	p.BaseBranch = getEnv("BASE_BRANCH", p.BaseBranch)
+	p.HeadBranchExcludes = splitEnv("HEAD_BRANCH_EXCLUDE", p.HeadBranchExcludes, ",")
+	p.WebhookURL = getEnv("WEBHOOK_URL", p.WebhookURL)
+	p.SlackCommandToken = getEnv("SLACK_COMMAND_TOKEN", p.SlackCommandToken)
+
+	// owners are supplied as name, org:name or user:name
+	if owners := splitEnv("GITHUB_OWNERS", nil, ","); owners != nil {
+		p.GithubOwners = parseOwners(owners)
+	}
+	p.ReportMode = getEnv("REPORT_MODE", p.ReportMode)
+	p.Repositories = splitEnv("GITHUB_REPOSITORIES", p.Repositories, ",")
+
+	// teams are supplied as slug or slug=webhook url
+	if teams := splitEnv("GITHUB_TEAMS", nil, ","); teams != nil {
+		p.Teams = parseTeams(teams)
+	}
+
+	p.RepoFilter.IncludeArchived = getBoolEnv(errs, "REPO_INCLUDE_ARCHIVED", p.RepoFilter.IncludeArchived)
+	p.RepoFilter.SkipForks = getBoolEnv(errs, "REPO_SKIP_FORKS", p.RepoFilter.SkipForks)
+	p.RepoFilter.SkipTemplates = getBoolEnv(errs, "REPO_SKIP_TEMPLATES", p.RepoFilter.SkipTemplates)
+	p.RepoFilter.Topics = splitEnv("REPO_TOPICS", p.RepoFilter.Topics, ",")
+	p.RepoFilter.Visibility = splitEnv("REPO_VISIBILITY", p.RepoFilter.Visibility, ",")
+	p.RepoFilter.Names = splitEnv("REPO_NAMES", p.RepoFilter.Names, ",")
+	p.RepoFilter.PushedWithinDays = getIntEnv(errs, "REPO_PUSHED_WITHIN_DAYS", p.RepoFilter.PushedWithinDays)
+
+	p.GithubAppID = getEnv("GITHUB_APP_ID", p.GithubAppID)
+	p.GithubAppPrivateKey = getEnv("GITHUB_APP_PRIVATE_KEY", p.GithubAppPrivateKey)
+	p.GithubAppInstallationID = getIntEnv(errs, "GITHUB_APP_INSTALLATION_ID", p.GithubAppInstallationID)
+
+	p.BaseBranchMode = getEnv("BASE_BRANCH_MODE", p.BaseBranchMode)
+	p.BaseBranchCandidates = splitEnv("BASE_BRANCH_CANDIDATES", p.BaseBranchCandidates, ",")
+
+	p.Mentions = splitEnv("MENTIONS", p.Mentions, ",")
+
+	// slack users are supplied as login=id
+	if users := splitEnv("SLACK_USERS", nil, ","); users != nil {
+		p.SlackUsers = parseSlackUsers(users)
+	}
+	p.SlackBotToken = getEnv("SLACK_BOT_TOKEN", p.SlackBotToken)
+	p.SlackAPIURL = getEnv("SLACK_API_URL", p.SlackAPIURL)
+	p.SlackChannel = getEnv("SLACK_CHANNEL", p.SlackChannel)
+
+	p.StateStore = getEnv("STATE_STORE", p.StateStore)
+	p.NotifyMode = getEnv("NOTIFY_MODE", p.NotifyMode)
+	p.DeltaThreshold = getIntEnv(errs, "DELTA_THRESHOLD", p.DeltaThreshold)
+	p.DigestDay = getEnv("DIGEST_DAY", p.DigestDay)
+
+	p.UpToDateMode = getEnv("UP_TO_DATE_MODE", p.UpToDateMode)
+
+	p.Templates.Summary = getEnv("TEMPLATE_SUMMARY", p.Templates.Summary)
+	p.Templates.Repo = getEnv("TEMPLATE_REPO", p.Templates.Repo)
+	p.Templates.Branch = getEnv("TEMPLATE_BRANCH", p.Templates.Branch)
+	p.Templates.UpToDate = getEnv("TEMPLATE_UP_TO_DATE", p.Templates.UpToDate)
+
+	// severity is supplied as a yaml or json document
+	if severity := getEnv("SEVERITY", ""); severity != "" {
+		p.Severity = Severity{}
+		if err := yaml.UnmarshalStrict([]byte(severity), &p.Severity); err != nil {
+			errs.add("SEVERITY", "could not be parsed: %v", err)
+		}
+	}
+
+	p.DriftIssues = getBoolEnv(errs, "DRIFT_ISSUES", p.DriftIssues)
+	p.DriftIssueLabel = getEnv("DRIFT_ISSUE_LABEL", p.DriftIssueLabel)
+
+	p.CommitStatus = getBoolEnv(errs, "COMMIT_STATUS", p.CommitStatus)
+	p.CommitStatusContext = getEnv("COMMIT_STATUS_CONTEXT", p.CommitStatusContext)
+	p.CommitStatusThreshold = getIntEnv(errs, "COMMIT_STATUS_THRESHOLD", p.CommitStatusThreshold)
+
+	p.IgnoreOpenPullRequests = getBoolEnv(errs, "IGNORE_OPEN_PULL_REQUESTS", p.IgnoreOpenPullRequests)
+
+	p.StaleBranchDays = getIntEnv(errs, "STALE_BRANCH_DAYS", p.StaleBranchDays)
+	p.StaleBranchesAll = getBoolEnv(errs, "STALE_BRANCH_ALL", p.StaleBranchesAll)
+
+	p.StaleBranchCleanup = getEnv("STALE_BRANCH_CLEANUP", p.StaleBranchCleanup)
+
+	// head branch prefixes can override the base branch using pattern=base
+	if prefixes := splitEnv("HEAD_BRANCH_PREFIX", nil, ","); prefixes != nil {
+		p.HeadBranchPrefixes, p.BaseOverrides = splitBaseOverrides(prefixes)
+	}
+
+	// branch rules are supplied as a yaml or json array
+	if rules := getEnv("BRANCH_RULES", ""); rules != "" {
+		p.BranchRules = nil
+		if err := yaml.UnmarshalStrict([]byte(rules), &p.BranchRules); err != nil {
+			errs.add("BRANCH_RULES", "could not be parsed: %v", err)
+		}
+	}
+
+	// escalations are supplied as a yaml or json array
+	if escalations := getEnv("ESCALATIONS", ""); escalations != "" {
+		p.Escalations = nil
+		if err := yaml.UnmarshalStrict([]byte(escalations), &p.Escalations); err != nil {
+			errs.add("ESCALATIONS", "could not be parsed: %v", err)
+		}
+	}
+}
+
+func splitBaseOverrides(prefixes []string) ([]string, map[string]string) {
+	var overrides map[string]string
+	patterns := make([]string, 0, len(prefixes))
+
+	for _, prefix := range prefixes {
+		pattern := prefix
+		if i := strings.LastIndex(prefix, "="); i >= 0 {
+			pattern = strings.TrimSpace(prefix[:i])
+
+			if overrides == nil {
+				overrides = make(map[string]string)
+			}
+			overrides[pattern] = strings.TrimSpace(prefix[i+1:])
+		}
+
+		patterns = append(patterns, pattern)
+	}
+
+	return patterns, overrides
+}
+
+func splitEnv(key string, fallback []string, delimeter string) []string {
+	value := getEnv(key, "")
+	if value == "" {
+		return fallback
+	}
+	return strings.Split(value, delimeter)
+}
+
+func getIntEnv(errs *ValidationError, key string, fallback int) int {
+	raw := getEnv(key, strconv.Itoa(fallback))
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		errs.add(key, "must be a whole number, got %q", raw)
+		return fallback
+	}
+	return value
+}
+
+func getBoolEnv(errs *ValidationError, key string, fallback bool) bool {
+	raw := getEnv(key, strconv.FormatBool(fallback))
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		errs.add(key, "must be true or false, got %q", raw)
+		return fallback
+	}
+	return value
 }
 
 func getEnv(key string, fallback string) string {
@@ -11,6 +11,7 @@ func TestParseParams(t *testing.T) {
 		name        string
 		envSupplier func()
 		want        *Params
+		wantErr     string
 	}{
 		{
 			name: "Test Happy path",
@@ -31,6 +32,12 @@ func TestParseParams(t *testing.T) {
 				HeadBranchPrefixes: []string{"release"},
 				WebhookURL:         "http://localhost.com",
 				SlackCommandToken:  "token",
+				BaseBranchMode:     BaseModeFixed,
+				StaleBranchCleanup: CleanupOff,
+				ReportMode:         ReportCombined,
+				NotifyMode:         NotifyFull,
+				DeltaThreshold:     1,
+				DigestDay:          "monday",
 			},
 		},
 
@@ -53,6 +60,12 @@ func TestParseParams(t *testing.T) {
 				HeadBranchPrefixes: []string{"release", "master"},
 				WebhookURL:         "http://localhost.com",
 				SlackCommandToken:  "token",
+				BaseBranchMode:     BaseModeFixed,
+				StaleBranchCleanup: CleanupOff,
+				ReportMode:         ReportCombined,
+				NotifyMode:         NotifyFull,
+				DeltaThreshold:     1,
+				DigestDay:          "monday",
 			},
 		},
 
@@ -75,31 +88,433 @@ func TestParseParams(t *testing.T) {
 				HeadBranchPrefixes: []string{"release:master"},
 				WebhookURL:         "http://localhost.com",
 				SlackCommandToken:  "token",
+				BaseBranchMode:     BaseModeFixed,
+				StaleBranchCleanup: CleanupOff,
+				ReportMode:         ReportCombined,
+				NotifyMode:         NotifyFull,
+				DeltaThreshold:     1,
+				DigestDay:          "monday",
 			},
 		},
 
 		{
-			name: "Test no environment variables path",
+			name: "Test ignore open pull requests path",
 			envSupplier: func() {
-				clearEnvs()
+				os.Setenv("GITHUB_BASE_URL", "http://localhost.com")
+				os.Setenv("GITHUB_TOKEN", "token")
+				os.Setenv("GITHUB_ORGANISATION", "org")
+				os.Setenv("BASE_BRANCH", "develop")
+				os.Setenv("HEAD_BRANCH_PREFIX", "master")
+				os.Setenv("WEBHOOK_URL", "http://localhost.com")
+				os.Setenv("SLACK_COMMAND_TOKEN", "token")
+				os.Setenv("IGNORE_OPEN_PULL_REQUESTS", "true")
+			},
+			want: &Params{
+				GithubBaseURL:          "http://localhost.com",
+				GithubToken:            "token",
+				GithubOrganization:     "org",
+				BaseBranch:             "develop",
+				HeadBranchPrefixes:     []string{"master"},
+				WebhookURL:             "http://localhost.com",
+				SlackCommandToken:      "token",
+				BaseBranchMode:         BaseModeFixed,
+				IgnoreOpenPullRequests: true,
+				StaleBranchCleanup:     CleanupOff,
+				ReportMode:             ReportCombined,
+				NotifyMode:             NotifyFull,
+				DeltaThreshold:         1,
+				DigestDay:              "monday",
+			},
+		},
+
+		{
+			name: "Test stale branches path",
+			envSupplier: func() {
+				os.Setenv("GITHUB_BASE_URL", "http://localhost.com")
+				os.Setenv("GITHUB_TOKEN", "token")
+				os.Setenv("GITHUB_ORGANISATION", "org")
+				os.Setenv("BASE_BRANCH", "develop")
+				os.Setenv("HEAD_BRANCH_PREFIX", "master")
+				os.Setenv("WEBHOOK_URL", "http://localhost.com")
+				os.Setenv("SLACK_COMMAND_TOKEN", "token")
+				os.Setenv("STALE_BRANCH_DAYS", "30")
+				os.Setenv("STALE_BRANCH_ALL", "true")
+				os.Setenv("STALE_BRANCH_CLEANUP", "dry-run")
 			},
 			want: &Params{
 				GithubBaseURL:      "http://localhost.com",
-				GithubToken:        "",
-				GithubOrganization: "",
+				GithubToken:        "token",
+				GithubOrganization: "org",
 				BaseBranch:         "develop",
 				HeadBranchPrefixes: []string{"master"},
 				WebhookURL:         "http://localhost.com",
-				SlackCommandToken:  "",
+				SlackCommandToken:  "token",
+				BaseBranchMode:     BaseModeFixed,
+				StaleBranchDays:    30,
+				StaleBranchesAll:   true,
+				StaleBranchCleanup: CleanupDryRun,
+				ReportMode:         ReportCombined,
+				NotifyMode:         NotifyFull,
+				DeltaThreshold:     1,
+				DigestDay:          "monday",
+			},
+		},
+
+		{
+			name: "Test invalid stale branch days path",
+			envSupplier: func() {
+				os.Setenv("GITHUB_BASE_URL", "http://localhost.com")
+				os.Setenv("GITHUB_TOKEN", "token")
+				os.Setenv("GITHUB_ORGANISATION", "org")
+				os.Setenv("BASE_BRANCH", "develop")
+				os.Setenv("HEAD_BRANCH_PREFIX", "master")
+				os.Setenv("WEBHOOK_URL", "http://localhost.com")
+				os.Setenv("SLACK_COMMAND_TOKEN", "token")
+				os.Setenv("STALE_BRANCH_DAYS", "thirty")
+			},
+			wantErr: "invalid configuration:\n  STALE_BRANCH_DAYS: must be a whole number, got \"thirty\"",
+		},
+
+		{
+			name: "Test branch patterns path",
+			envSupplier: func() {
+				os.Setenv("GITHUB_BASE_URL", "http://localhost.com")
+				os.Setenv("GITHUB_TOKEN", "token")
+				os.Setenv("GITHUB_ORGANISATION", "org")
+				os.Setenv("BASE_BRANCH", "develop")
+				os.Setenv("HEAD_BRANCH_PREFIX", "release/*,hotfix/**")
+				os.Setenv("HEAD_BRANCH_EXCLUDE", "release/legacy-*")
+				os.Setenv("WEBHOOK_URL", "http://localhost.com")
+				os.Setenv("SLACK_COMMAND_TOKEN", "token")
+			},
+			want: &Params{
+				GithubBaseURL:      "http://localhost.com",
+				GithubToken:        "token",
+				GithubOrganization: "org",
+				BaseBranch:         "develop",
+				HeadBranchPrefixes: []string{"release/*", "hotfix/**"},
+				HeadBranchExcludes: []string{"release/legacy-*"},
+				WebhookURL:         "http://localhost.com",
+				SlackCommandToken:  "token",
+				BaseBranchMode:     BaseModeFixed,
+				StaleBranchCleanup: CleanupOff,
+				ReportMode:         ReportCombined,
+				NotifyMode:         NotifyFull,
+				DeltaThreshold:     1,
+				DigestDay:          "monday",
 			},
 		},
+
+		{
+			name: "Test base branch overrides path",
+			envSupplier: func() {
+				os.Setenv("GITHUB_BASE_URL", "http://localhost.com")
+				os.Setenv("GITHUB_TOKEN", "token")
+				os.Setenv("GITHUB_ORGANISATION", "org")
+				os.Setenv("BASE_BRANCH", "develop")
+				os.Setenv("HEAD_BRANCH_PREFIX", "release/*=main, hotfix/** = develop,master")
+				os.Setenv("WEBHOOK_URL", "http://localhost.com")
+				os.Setenv("SLACK_COMMAND_TOKEN", "token")
+			},
+			want: &Params{
+				GithubBaseURL:      "http://localhost.com",
+				GithubToken:        "token",
+				GithubOrganization: "org",
+				BaseBranch:         "develop",
+				HeadBranchPrefixes: []string{"release/*", "hotfix/**", "master"},
+				BaseOverrides:      map[string]string{"release/*": "main", "hotfix/**": "develop"},
+				WebhookURL:         "http://localhost.com",
+				SlackCommandToken:  "token",
+				BaseBranchMode:     BaseModeFixed,
+				StaleBranchCleanup: CleanupOff,
+				ReportMode:         ReportCombined,
+				NotifyMode:         NotifyFull,
+				DeltaThreshold:     1,
+				DigestDay:          "monday",
+			},
+		},
+
+		{
+			name: "Test repo base branch mode path",
+			envSupplier: func() {
+				os.Setenv("GITHUB_BASE_URL", "http://localhost.com")
+				os.Setenv("GITHUB_TOKEN", "token")
+				os.Setenv("GITHUB_ORGANISATION", "org")
+				os.Setenv("HEAD_BRANCH_PREFIX", "master")
+				os.Setenv("WEBHOOK_URL", "http://localhost.com")
+				os.Setenv("SLACK_COMMAND_TOKEN", "token")
+				os.Setenv("BASE_BRANCH_MODE", "repo")
+				os.Setenv("BASE_BRANCH_CANDIDATES", "develop,main")
+			},
+			want: &Params{
+				GithubBaseURL:        "http://localhost.com",
+				GithubToken:          "token",
+				GithubOrganization:   "org",
+				BaseBranch:           "develop",
+				HeadBranchPrefixes:   []string{"master"},
+				WebhookURL:           "http://localhost.com",
+				SlackCommandToken:    "token",
+				BaseBranchMode:       BaseModeRepo,
+				BaseBranchCandidates: []string{"develop", "main"},
+				StaleBranchCleanup:   CleanupOff,
+				ReportMode:           ReportCombined,
+				NotifyMode:           NotifyFull,
+				DeltaThreshold:       1,
+				DigestDay:            "monday",
+			},
+		},
+
+		{
+			name: "Test multiple owners path",
+			envSupplier: func() {
+				os.Setenv("GITHUB_BASE_URL", "http://localhost.com")
+				os.Setenv("GITHUB_TOKEN", "token")
+				os.Setenv("GITHUB_OWNERS", "org-one, org:org-two,user:someone")
+				os.Setenv("REPORT_MODE", "per-owner")
+				os.Setenv("WEBHOOK_URL", "http://localhost.com")
+			},
+			want: &Params{
+				GithubBaseURL:      "http://localhost.com",
+				GithubToken:        "token",
+				BaseBranch:         "develop",
+				HeadBranchPrefixes: []string{"master"},
+				WebhookURL:         "http://localhost.com",
+				BaseBranchMode:     BaseModeFixed,
+				StaleBranchCleanup: CleanupOff,
+				ReportMode:         ReportPerOwner,
+				NotifyMode:         NotifyFull,
+				DeltaThreshold:     1,
+				DigestDay:          "monday",
+				GithubOwners: []Owner{
+					{Name: "org-one", Type: OwnerOrg},
+					{Name: "org-two", Type: OwnerOrg},
+					{Name: "someone", Type: OwnerUser},
+				},
+			},
+		},
+
+		{
+			name: "Test repositories path",
+			envSupplier: func() {
+				os.Setenv("GITHUB_BASE_URL", "http://localhost.com")
+				os.Setenv("GITHUB_TOKEN", "token")
+				os.Setenv("GITHUB_REPOSITORIES", "org/api,someone/dotfiles")
+				os.Setenv("WEBHOOK_URL", "http://localhost.com")
+			},
+			want: &Params{
+				GithubBaseURL:      "http://localhost.com",
+				GithubToken:        "token",
+				BaseBranch:         "develop",
+				HeadBranchPrefixes: []string{"master"},
+				WebhookURL:         "http://localhost.com",
+				BaseBranchMode:     BaseModeFixed,
+				StaleBranchCleanup: CleanupOff,
+				ReportMode:         ReportCombined,
+				NotifyMode:         NotifyFull,
+				DeltaThreshold:     1,
+				DigestDay:          "monday",
+				Repositories:       []string{"org/api", "someone/dotfiles"},
+			},
+		},
+
+		{
+			name: "Test teams path",
+			envSupplier: func() {
+				os.Setenv("GITHUB_BASE_URL", "http://localhost.com")
+				os.Setenv("GITHUB_TOKEN", "token")
+				os.Setenv("GITHUB_ORGANISATION", "org")
+				os.Setenv("GITHUB_TEAMS", "payments=https://hooks.slack.com/payments, platform")
+				os.Setenv("WEBHOOK_URL", "http://localhost.com")
+			},
+			want: &Params{
+				GithubBaseURL:      "http://localhost.com",
+				GithubToken:        "token",
+				GithubOrganization: "org",
+				BaseBranch:         "develop",
+				HeadBranchPrefixes: []string{"master"},
+				WebhookURL:         "http://localhost.com",
+				BaseBranchMode:     BaseModeFixed,
+				StaleBranchCleanup: CleanupOff,
+				ReportMode:         ReportCombined,
+				NotifyMode:         NotifyFull,
+				DeltaThreshold:     1,
+				DigestDay:          "monday",
+				Teams:              []Team{{Slug: "payments", WebhookURL: "https://hooks.slack.com/payments"}, {Slug: "platform"}},
+			},
+		},
+
+		{
+			name: "Test mentions, state, notify mode, escalations, templates, severity, issues and statuses path",
+			envSupplier: func() {
+				os.Setenv("GITHUB_BASE_URL", "http://localhost.com")
+				os.Setenv("GITHUB_TOKEN", "token")
+				os.Setenv("GITHUB_ORGANISATION", "org")
+				os.Setenv("MENTIONS", "codeowners,authors")
+				os.Setenv("SLACK_USERS", "octocat=U123, org/platform=S456")
+				os.Setenv("SLACK_BOT_TOKEN", "xoxb-token")
+				os.Setenv("SLACK_API_URL", "http://localhost.com/api")
+				os.Setenv("STATE_STORE", "dynamodb:branch-bot-state")
+				os.Setenv("NOTIFY_MODE", "delta")
+				os.Setenv("DELTA_THRESHOLD", "5")
+				os.Setenv("DIGEST_DAY", "friday")
+				os.Setenv("ESCALATIONS", `[{days: 3, mention: true}, {days: 14, issue: true, labels: [branch-drift]}]`)
+				os.Setenv("TEMPLATE_SUMMARY", "*Branch drift in {{.Org}}*")
+				os.Setenv("TEMPLATE_UP_TO_DATE", "all caught up with {{.Base}}")
+				os.Setenv("SEVERITY", `{minimum: warning, warning: {ahead: 5}}`)
+				os.Setenv("DRIFT_ISSUES", "true")
+				os.Setenv("DRIFT_ISSUE_LABEL", "drift")
+				os.Setenv("COMMIT_STATUS", "true")
+				os.Setenv("COMMIT_STATUS_CONTEXT", "drift")
+				os.Setenv("COMMIT_STATUS_THRESHOLD", "10")
+				os.Setenv("SLACK_CHANNEL", "C1234")
+				os.Setenv("UP_TO_DATE_MODE", "thread")
+				os.Setenv("WEBHOOK_URL", "http://localhost.com")
+			},
+			want: &Params{
+				GithubBaseURL:         "http://localhost.com",
+				GithubToken:           "token",
+				GithubOrganization:    "org",
+				BaseBranch:            "develop",
+				HeadBranchPrefixes:    []string{"master"},
+				WebhookURL:            "http://localhost.com",
+				BaseBranchMode:        BaseModeFixed,
+				StaleBranchCleanup:    CleanupOff,
+				ReportMode:            ReportCombined,
+				NotifyMode:            NotifyDelta,
+				DeltaThreshold:        5,
+				DigestDay:             "friday",
+				Mentions:              []string{MentionCodeOwners, MentionAuthors},
+				SlackUsers:            map[string]string{"octocat": "U123", "org/platform": "S456"},
+				SlackBotToken:         "xoxb-token",
+				SlackAPIURL:           "http://localhost.com/api",
+				StateStore:            "dynamodb:branch-bot-state",
+				Escalations:           []Escalation{{Days: 3, Mention: true}, {Days: 14, Issue: true, Labels: []string{"branch-drift"}}},
+				Templates:             MessageTemplates{Summary: "*Branch drift in {{.Org}}*", UpToDate: "all caught up with {{.Base}}"},
+				Severity:              Severity{SeverityThresholds: SeverityThresholds{Warning: Threshold{Ahead: 5}}, Minimum: SeverityWarning},
+				DriftIssues:           true,
+				DriftIssueLabel:       "drift",
+				CommitStatus:          true,
+				CommitStatusContext:   "drift",
+				CommitStatusThreshold: 10,
+				SlackChannel:          "C1234",
+				UpToDateMode:          UpToDateThread,
+			},
+		},
+
+		{
+			name: "Test repo filter path",
+			envSupplier: func() {
+				os.Setenv("GITHUB_BASE_URL", "http://localhost.com")
+				os.Setenv("GITHUB_TOKEN", "token")
+				os.Setenv("GITHUB_ORGANISATION", "org")
+				os.Setenv("WEBHOOK_URL", "http://localhost.com")
+				os.Setenv("REPO_INCLUDE_ARCHIVED", "true")
+				os.Setenv("REPO_SKIP_FORKS", "true")
+				os.Setenv("REPO_SKIP_TEMPLATES", "true")
+				os.Setenv("REPO_TOPICS", "service,library")
+				os.Setenv("REPO_VISIBILITY", "private,internal")
+				os.Setenv("REPO_NAMES", "*,!sandbox-*")
+				os.Setenv("REPO_PUSHED_WITHIN_DAYS", "90")
+			},
+			want: &Params{
+				GithubBaseURL:      "http://localhost.com",
+				GithubToken:        "token",
+				GithubOrganization: "org",
+				BaseBranch:         "develop",
+				HeadBranchPrefixes: []string{"master"},
+				WebhookURL:         "http://localhost.com",
+				BaseBranchMode:     BaseModeFixed,
+				StaleBranchCleanup: CleanupOff,
+				ReportMode:         ReportCombined,
+				NotifyMode:         NotifyFull,
+				DeltaThreshold:     1,
+				DigestDay:          "monday",
+				RepoFilter: RepoFilter{
+					IncludeArchived:  true,
+					SkipForks:        true,
+					SkipTemplates:    true,
+					Topics:           []string{"service", "library"},
+					Visibility:       []string{"private", "internal"},
+					Names:            []string{"*", "!sandbox-*"},
+					PushedWithinDays: 90,
+				},
+			},
+		},
+
+		{
+			name: "Test branch rules path",
+			envSupplier: func() {
+				os.Setenv("GITHUB_BASE_URL", "http://localhost.com")
+				os.Setenv("GITHUB_TOKEN", "token")
+				os.Setenv("GITHUB_ORGANISATION", "org")
+				os.Setenv("BASE_BRANCH", "develop")
+				os.Setenv("WEBHOOK_URL", "http://localhost.com")
+				os.Setenv("SLACK_COMMAND_TOKEN", "token")
+				os.Setenv("BRANCH_RULES", `[
+					{"name": "release", "base": "main", "head": ["release/*"], "threshold": 2},
+					{"name": "main", "base": "develop", "head": ["main"], "template": "{{.Head}} needs merging into {{.Base}}"}
+				]`)
+			},
+			want: &Params{
+				GithubBaseURL:      "http://localhost.com",
+				GithubToken:        "token",
+				GithubOrganization: "org",
+				BaseBranch:         "develop",
+				HeadBranchPrefixes: []string{"master"},
+				WebhookURL:         "http://localhost.com",
+				SlackCommandToken:  "token",
+				BaseBranchMode:     BaseModeFixed,
+				StaleBranchCleanup: CleanupOff,
+				ReportMode:         ReportCombined,
+				NotifyMode:         NotifyFull,
+				DeltaThreshold:     1,
+				DigestDay:          "monday",
+				BranchRules: []Rule{
+					{Name: "release", Base: "main", Head: []string{"release/*"}, Threshold: 2},
+					{Name: "main", Base: "develop", Head: []string{"main"}, Template: "{{.Head}} needs merging into {{.Base}}"},
+				},
+			},
+		},
+
+		{
+			name: "Test no environment variables path",
+			envSupplier: func() {
+				clearEnvs()
+			},
+			wantErr: "invalid configuration:\n" +
+				"  github_token: is required, set GITHUB_TOKEN or github_token in the config document\n" +
+				"  github_organisation: is required, set GITHUB_ORGANISATION or github_organisation in the config document",
+		},
+
+		{
+			name: "Test invalid settings path",
+			envSupplier: func() {
+				os.Setenv("GITHUB_BASE_URL", "api.github.com")
+				os.Setenv("GITHUB_TOKEN", "token")
+				os.Setenv("HEAD_BRANCH_PREFIX", "release=,re:(")
+				os.Setenv("WEBHOOK_URL", "/services/T000")
+				os.Setenv("IGNORE_OPEN_PULL_REQUESTS", "sometimes")
+			},
+			wantErr: "invalid configuration:\n" +
+				"  IGNORE_OPEN_PULL_REQUESTS: must be true or false, got \"sometimes\"\n" +
+				"  github_organisation: is required, set GITHUB_ORGANISATION or github_organisation in the config document\n" +
+				"  github_base_url: must be an absolute http or https url, got \"api.github.com\"\n" +
+				"  webhook_url: must be an absolute http or https url, got \"/services/T000\"\n" +
+				"  head_branch_prefixes[1]: invalid branch patterns: pattern \"re:(\": error parsing regexp: missing closing ): `(`\n" +
+				"  base_overrides[\"release\"]: must name a base branch, use pattern=base",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// set environment variables
 			tt.envSupplier()
 
-			if got := ParseParams(); !reflect.DeepEqual(got, tt.want) {
+			got, err := ParseParams()
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("ParseParams() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("ParseParams() = %v, want %v", got, tt.want)
 			}
 
@@ -116,4 +531,49 @@ func clearEnvs() {
 	os.Setenv("HEAD_BRANCH_PREFIX", "")
 	os.Setenv("WEBHOOK_URL", "")
 	os.Setenv("SLACK_COMMAND_TOKEN", "")
+	os.Setenv("IGNORE_OPEN_PULL_REQUESTS", "")
+	os.Setenv("STALE_BRANCH_DAYS", "")
+	os.Setenv("STALE_BRANCH_ALL", "")
+	os.Setenv("STALE_BRANCH_CLEANUP", "")
+	os.Setenv("HEAD_BRANCH_EXCLUDE", "")
+	os.Setenv("BASE_BRANCH_MODE", "")
+	os.Setenv("BASE_BRANCH_CANDIDATES", "")
+	os.Setenv("BRANCH_RULES", "")
+	os.Setenv("CONFIG_FILE", "")
+	os.Setenv("CONFIG_DOCUMENT", "")
+	os.Setenv("GITHUB_OWNERS", "")
+	os.Setenv("REPORT_MODE", "")
+	os.Setenv("GITHUB_REPOSITORIES", "")
+	os.Setenv("GITHUB_TEAMS", "")
+	os.Setenv("MENTIONS", "")
+	os.Setenv("SLACK_USERS", "")
+	os.Setenv("SLACK_BOT_TOKEN", "")
+	os.Setenv("SLACK_API_URL", "")
+	os.Setenv("STATE_STORE", "")
+	os.Setenv("NOTIFY_MODE", "")
+	os.Setenv("DELTA_THRESHOLD", "")
+	os.Setenv("DIGEST_DAY", "")
+	os.Setenv("ESCALATIONS", "")
+	os.Setenv("TEMPLATE_SUMMARY", "")
+	os.Setenv("TEMPLATE_REPO", "")
+	os.Setenv("TEMPLATE_BRANCH", "")
+	os.Setenv("TEMPLATE_UP_TO_DATE", "")
+	os.Setenv("SEVERITY", "")
+	os.Setenv("DRIFT_ISSUES", "")
+	os.Setenv("DRIFT_ISSUE_LABEL", "")
+	os.Setenv("COMMIT_STATUS", "")
+	os.Setenv("COMMIT_STATUS_CONTEXT", "")
+	os.Setenv("COMMIT_STATUS_THRESHOLD", "")
+	os.Setenv("SLACK_CHANNEL", "")
+	os.Setenv("UP_TO_DATE_MODE", "")
+	os.Setenv("REPO_INCLUDE_ARCHIVED", "")
+	os.Setenv("REPO_SKIP_FORKS", "")
+	os.Setenv("REPO_SKIP_TEMPLATES", "")
+	os.Setenv("REPO_TOPICS", "")
+	os.Setenv("REPO_VISIBILITY", "")
+	os.Setenv("REPO_NAMES", "")
+	os.Setenv("REPO_PUSHED_WITHIN_DAYS", "")
+	os.Setenv("GITHUB_APP_ID", "")
+	os.Setenv("GITHUB_APP_PRIVATE_KEY", "")
+	os.Setenv("GITHUB_APP_INSTALLATION_ID", "")
 }
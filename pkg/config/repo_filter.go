@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/aaron-vaz/github-branch-bot/pkg/match"
+)
+
+// Repo visibilities
+const (
+	VisibilityPublic   = "public"
+	VisibilityPrivate  = "private"
+	VisibilityInternal = "internal"
+)
+
+// RepoFilter selects the repos that are checked, disabled repos are never checked
+type RepoFilter struct {
+	// IncludeArchived checks archived repos, they are skipped by default as nothing can be merged into them
+	IncludeArchived bool `json:"include_archived" yaml:"include_archived"`
+	SkipForks       bool `json:"skip_forks" yaml:"skip_forks"`
+	SkipTemplates   bool `json:"skip_templates" yaml:"skip_templates"`
+	// Topics only checks repos that have at least one of the topics
+	Topics []string `json:"topics" yaml:"topics"`
+	// Visibility only checks repos with one of the visibilities, one of public, private or internal
+	Visibility []string `json:"visibility" yaml:"visibility"`
+	// Names are the name patterns of the repos to check, patterns starting with ! exclude repos
+	Names []string `json:"names" yaml:"names"`
+	// PushedWithinDays only checks repos that have been pushed to in this many days, 0 disables the check
+	PushedWithinDays int `json:"pushed_within_days" yaml:"pushed_within_days"`
+}
+
+// Matcher compiles the name patterns of the filter, it returns nil when every name is included
+func (f *RepoFilter) Matcher() (*match.Matcher, error) {
+	if len(f.Names) == 0 {
+		return nil, nil
+	}
+	return match.Compile(f.Names, nil)
+}
+
+func (f *RepoFilter) validate(errs *ValidationError, path string) {
+	validatePatterns(errs, path+".names", f.Names)
+
+	for i, visibility := range f.Visibility {
+		if !oneOf(visibility, VisibilityPublic, VisibilityPrivate, VisibilityInternal) {
+			errs.add(fmt.Sprintf("%s.visibility[%d]", path, i), "must be one of %s, %s or %s, got %q", VisibilityPublic, VisibilityPrivate, VisibilityInternal, visibility)
+		}
+	}
+
+	if f.PushedWithinDays < 0 {
+		errs.add(path+".pushed_within_days", "must not be negative, got %d", f.PushedWithinDays)
+	}
+}
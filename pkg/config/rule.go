@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"text/template"
+
+	"github.com/aaron-vaz/github-branch-bot/pkg/match"
+)
+
+// Rule is a single comparison performed on every repo, branches matching the Head patterns are compared against Base
+type Rule struct {
+	Name string `json:"name" yaml:"name"`
+	// Base is the branch to compare against, when empty the base branch of the repo is used
+	Base    string   `json:"base" yaml:"base"`
+	Head    []string `json:"head" yaml:"head"`
+	Exclude []string `json:"exclude" yaml:"exclude"`
+	// Threshold is the number of commits a branch has to be ahead by before it is reported, defaults to 1
+	Threshold int `json:"threshold" yaml:"threshold"`
+	// Template is a text/template used to build the message for each branch that is ahead
+	Template string `json:"template" yaml:"template"`
+	// BaseOverrides maps a head pattern to the base branch its branches are compared against instead of Base
+	BaseOverrides map[string]string `json:"base_overrides" yaml:"base_overrides"`
+	// Severity replaces the default severity thresholds for the branches of this rule
+	Severity *SeverityThresholds `json:"severity" yaml:"severity"`
+}
+
+// Matcher compiles the head and exclude patterns of the rule
+// patterns can be plain prefixes, globs such as release/* or regular expressions such as re:^release/\d+$
+func (r *Rule) Matcher() (*match.Matcher, error) {
+	return match.Compile(r.Head, r.Exclude)
+}
+
+// BaseBranchFor returns the base branch that branches selected by the supplied pattern are compared against
+// when neither the pattern nor the rule override it the repo base is used
+func (r *Rule) BaseBranchFor(pattern, repoBase string) string {
+	if base, ok := r.BaseOverrides[pattern]; ok {
+		return base
+	}
+
+	if r.Base != "" {
+		return r.Base
+	}
+	return repoBase
+}
+
+// MinAhead returns the number of commits a branch has to be ahead by before it is reported
+func (r *Rule) MinAhead() int {
+	if r.Threshold < 1 {
+		return 1
+	}
+	return r.Threshold
+}
+
+// Validate checks that the patterns and template of the rule can be compiled
+func (r *Rule) Validate() error {
+	errs := &ValidationError{}
+	r.validate(errs, "rule")
+
+	if len(errs.Problems) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (r *Rule) validate(errs *ValidationError, path string) {
+	if len(r.Head) == 0 {
+		errs.add(path+".head", "at least one head pattern is required")
+	}
+
+	validatePatterns(errs, path+".head", r.Head)
+	validatePatterns(errs, path+".exclude", r.Exclude)
+	validateBaseOverrides(errs, path+".base_overrides", r.BaseOverrides, r.Head)
+
+	if r.Threshold < 0 {
+		errs.add(path+".threshold", "must not be negative, got %d", r.Threshold)
+	}
+
+	if r.Severity != nil {
+		r.Severity.validate(errs, path+".severity")
+	}
+
+	if _, err := template.New(r.Name).Parse(r.Template); err != nil {
+		errs.add(path+".template", "%v", err)
+	}
+}
+
+func validatePatterns(errs *ValidationError, field string, patterns []string) {
+	for i, pattern := range patterns {
+		if _, err := match.Compile([]string{pattern}, nil); err != nil {
+			errs.add(fmt.Sprintf("%s[%d]", field, i), "%v", err)
+		}
+	}
+}
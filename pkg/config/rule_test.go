@@ -0,0 +1,151 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRule_Matcher(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    *Rule
+		branch  string
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:   "Test glob path",
+			rule:   &Rule{Head: []string{"release/*"}},
+			branch: "release/1.0",
+			want:   true,
+		},
+		{
+			name:   "Test excluded path",
+			rule:   &Rule{Head: []string{"release/*"}, Exclude: []string{"release/legacy-*"}},
+			branch: "release/legacy-1",
+			want:   false,
+		},
+		{
+			name:    "Test invalid pattern path",
+			rule:    &Rule{Head: []string{"re:release/("}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := tt.rule.Matcher()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Rule.Matcher() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil && matcher.Match(tt.branch) != tt.want {
+				t.Errorf("Rule.Matcher().Match(%q) = %v, want %v", tt.branch, !tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestRule_BaseBranchFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     *Rule
+		pattern  string
+		repoBase string
+		want     string
+	}{
+		{
+			name:     "Test pattern override path",
+			rule:     &Rule{Base: "develop", BaseOverrides: map[string]string{"release/*": "main"}},
+			pattern:  "release/*",
+			repoBase: "master",
+			want:     "main",
+		},
+		{
+			name:     "Test rule base path",
+			rule:     &Rule{Base: "develop", BaseOverrides: map[string]string{"release/*": "main"}},
+			pattern:  "hotfix/*",
+			repoBase: "master",
+			want:     "develop",
+		},
+		{
+			name:     "Test repo base path",
+			rule:     &Rule{},
+			pattern:  "master",
+			repoBase: "main",
+			want:     "main",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.BaseBranchFor(tt.pattern, tt.repoBase); got != tt.want {
+				t.Errorf("Rule.BaseBranchFor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRule_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    *Rule
+		wantErr bool
+	}{
+		{
+			name: "Test Happy path",
+			rule: &Rule{Name: "release", Head: []string{"release/*"}, Template: "{{.Head}} needs merging into {{.Base}}"},
+		},
+		{
+			name:    "Test invalid pattern path",
+			rule:    &Rule{Name: "release", Head: []string{"re:("}},
+			wantErr: true,
+		},
+		{
+			name:    "Test invalid template path",
+			rule:    &Rule{Name: "release", Head: []string{"release/*"}, Template: "{{.Head"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rule.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Rule.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParams_Rules(t *testing.T) {
+	configured := []Rule{{Name: "release", Base: "main", Head: []string{"release/*"}}}
+
+	tests := []struct {
+		name   string
+		params *Params
+		want   []Rule
+	}{
+		{
+			name:   "Test configured rules path",
+			params: &Params{HeadBranchPrefixes: []string{"master"}, BranchRules: configured},
+			want:   configured,
+		},
+		{
+			name: "Test head branch prefixes path",
+			params: &Params{
+				HeadBranchPrefixes: []string{"master", "release/*"},
+				HeadBranchExcludes: []string{"release/legacy-*"},
+				BaseOverrides:      map[string]string{"release/*": "main"},
+			},
+			want: []Rule{{
+				Head:          []string{"master", "release/*"},
+				Exclude:       []string{"release/legacy-*"},
+				BaseOverrides: map[string]string{"release/*": "main"},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.params.Rules(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Params.Rules() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// SecretProvider resolves the name of a secret to its value
+type SecretProvider interface {
+	GetSecret(name string) (string, error)
+}
+
+// SecretProviders maps the prefix of a secret reference to the provider that resolves it
+// a setting written as ssm:/branch-bot/github-token is resolved by the ssm provider using the name /branch-bot/github-token
+var SecretProviders = map[string]SecretProvider{
+	"ssm":            SSMProvider{},
+	"secretsmanager": SecretsManagerProvider{},
+}
+
+// resolved secrets are kept for the lifetime of the process so warm invocations do not fetch them again
+var secretCache = struct {
+	sync.Mutex
+	values map[string]string
+}{values: make(map[string]string)}
+
+// ResolveSecret returns the value the supplied setting refers to
+// settings that are not secret references are returned unchanged
+func ResolveSecret(value string) (string, error) {
+	i := strings.Index(value, ":")
+	if i < 0 {
+		return value, nil
+	}
+
+	provider, ok := SecretProviders[value[:i]]
+	if !ok {
+		return value, nil
+	}
+
+	secretCache.Lock()
+	defer secretCache.Unlock()
+
+	if secret, ok := secretCache.values[value]; ok {
+		return secret, nil
+	}
+
+	secret, err := provider.GetSecret(value[i+1:])
+	if err != nil {
+		return "", err
+	}
+
+	secretCache.values[value] = secret
+	return secret, nil
+}
+
+// resolveSecrets replaces the secret references in the params with their values
+func (p *Params) resolveSecrets(errs *ValidationError) {
+	resolve := func(field string, value *string) {
+		secret, err := ResolveSecret(*value)
+		if err != nil {
+			errs.add(field, "could not resolve secret %q: %v", *value, err)
+			return
+		}
+		*value = secret
+	}
+
+	resolve("github_token", &p.GithubToken)
+	resolve("webhook_url", &p.WebhookURL)
+	resolve("slack_command_token", &p.SlackCommandToken)
+	resolve("slack_bot_token", &p.SlackBotToken)
+	resolve("github_app_private_key", &p.GithubAppPrivateKey)
+
+	for i := range p.Escalations {
+		resolve(fmt.Sprintf("escalations[%d].webhook_url", i), &p.Escalations[i].WebhookURL)
+	}
+
+	for i := range p.Teams {
+		resolve(fmt.Sprintf("teams[%d].webhook_url", i), &p.Teams[i].WebhookURL)
+	}
+
+	for i, owner := range p.GithubOwners {
+		resolve(fmt.Sprintf("owners[%d].token", i), &p.GithubOwners[i].Token)
+
+		for j := range owner.Teams {
+			resolve(fmt.Sprintf("owners[%d].teams[%d].webhook_url", i, j), &p.GithubOwners[i].Teams[j].WebhookURL)
+		}
+	}
+}
+
+// SSMProvider reads secrets from SSM Parameter Store, SecureString parameters are decrypted
+type SSMProvider struct{}
+
+// GetSecret returns the value of the named parameter
+func (SSMProvider) GetSecret(name string) (string, error) {
+	sess, err := session.NewSession()
+	if err != nil {
+		return "", err
+	}
+
+	output, err := ssm.New(sess).GetParameter(&ssm.GetParameterInput{Name: aws.String(name), WithDecryption: aws.Bool(true)})
+	if err != nil {
+		return "", err
+	}
+
+	return aws.StringValue(output.Parameter.Value), nil
+}
+
+// SecretsManagerProvider reads secrets from AWS Secrets Manager, the name can be the secret name or its arn
+type SecretsManagerProvider struct{}
+
+// GetSecret returns the string value of the named secret
+func (SecretsManagerProvider) GetSecret(name string) (string, error) {
+	sess, err := session.NewSession()
+	if err != nil {
+		return "", err
+	}
+
+	output, err := secretsmanager.New(sess).GetSecretValue(&secretsmanager.GetSecretValueInput{SecretId: aws.String(name)})
+	if err != nil {
+		return "", err
+	}
+
+	return aws.StringValue(output.SecretString), nil
+}
+
+// MemoryProvider serves secrets from a map, it is intended for tests and local runs
+type MemoryProvider struct {
+	Secrets map[string]string
+}
+
+// GetSecret returns the named secret from the map
+func (m *MemoryProvider) GetSecret(name string) (string, error) {
+	secret, ok := m.Secrets[name]
+	if !ok {
+		return "", fmt.Errorf("secret %s not found", name)
+	}
+	return secret, nil
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestResolveSecret(t *testing.T) {
+	memory := &MemoryProvider{Secrets: map[string]string{
+		"/branch-bot/github-token":                                       "ssm-token",
+		"arn:aws:secretsmanager:eu-west-2:123456789012:secret:webhook-x": "https://hooks.slack.com/services/T000",
+	}}
+
+	SecretProviders = map[string]SecretProvider{"ssm": memory, "secretsmanager": memory}
+	defer resetSecrets()
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "Test plain value path",
+			value: "token",
+			want:  "token",
+		},
+		{
+			name:  "Test url value path",
+			value: "https://hooks.slack.com/services/T000",
+			want:  "https://hooks.slack.com/services/T000",
+		},
+		{
+			name:  "Test ssm reference path",
+			value: "ssm:/branch-bot/github-token",
+			want:  "ssm-token",
+		},
+		{
+			name:  "Test secrets manager arn reference path",
+			value: "secretsmanager:arn:aws:secretsmanager:eu-west-2:123456789012:secret:webhook-x",
+			want:  "https://hooks.slack.com/services/T000",
+		},
+		{
+			name:    "Test missing secret path",
+			value:   "ssm:/branch-bot/missing",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveSecret(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ResolveSecret() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if got != tt.want {
+				t.Errorf("ResolveSecret() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveSecret_Cache(t *testing.T) {
+	memory := &MemoryProvider{Secrets: map[string]string{"/branch-bot/cached": "first"}}
+
+	SecretProviders = map[string]SecretProvider{"ssm": memory}
+	defer resetSecrets()
+
+	if got, _ := ResolveSecret("ssm:/branch-bot/cached"); got != "first" {
+		t.Errorf("ResolveSecret() = %v, want %v", got, "first")
+	}
+
+	// the provider is not asked again once the secret has been resolved
+	memory.Secrets["/branch-bot/cached"] = "second"
+	if got, _ := ResolveSecret("ssm:/branch-bot/cached"); got != "first" {
+		t.Errorf("ResolveSecret() cached = %v, want %v", got, "first")
+	}
+}
+
+func TestParseParams_Secrets(t *testing.T) {
+	SecretProviders = map[string]SecretProvider{"ssm": &MemoryProvider{Secrets: map[string]string{
+		"/branch-bot/github-token":  "resolved-token",
+		"/branch-bot/webhook-url":   "https://hooks.slack.com/services/T000",
+		"/branch-bot/slack-command": "resolved-command-token",
+	}}}
+	defer resetSecrets()
+	defer clearEnvs()
+
+	os.Setenv("GITHUB_TOKEN", "ssm:/branch-bot/github-token")
+	os.Setenv("GITHUB_ORGANISATION", "org")
+	os.Setenv("WEBHOOK_URL", "ssm:/branch-bot/webhook-url")
+	os.Setenv("SLACK_COMMAND_TOKEN", "ssm:/branch-bot/slack-command")
+
+	got, err := ParseParams(WebhookURLSetting, SlackCommandTokenSetting)
+	if err != nil {
+		t.Fatalf("ParseParams() unexpected error = %v", err)
+	}
+
+	if got.GithubToken != "resolved-token" || got.WebhookURL != "https://hooks.slack.com/services/T000" || got.SlackCommandToken != "resolved-command-token" {
+		t.Errorf("ParseParams() did not resolve secrets, got = %+v", got)
+	}
+
+	os.Setenv("GITHUB_TOKEN", "ssm:/branch-bot/unknown")
+
+	want := "invalid configuration:\n" +
+		"  github_token: could not resolve secret \"ssm:/branch-bot/unknown\": secret /branch-bot/unknown not found"
+	if _, err := ParseParams(); err == nil || err.Error() != want {
+		t.Errorf("ParseParams() error = %v, want %v", err, want)
+	}
+}
+
+func resetSecrets() {
+	SecretProviders = map[string]SecretProvider{"ssm": SSMProvider{}, "secretsmanager": SecretsManagerProvider{}}
+	secretCache.values = make(map[string]string)
+}
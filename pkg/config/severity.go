@@ -0,0 +1,163 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Severity levels, in ascending order
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// severityLevels are the severity levels in ascending order
+var severityLevels = []string{SeverityInfo, SeverityWarning, SeverityCritical}
+
+// defaultSeverityEmoji prefix the messages of each severity level when no emoji are configured
+var defaultSeverityEmoji = map[string]string{
+	SeverityInfo:     ":large_blue_circle:",
+	SeverityWarning:  ":warning:",
+	SeverityCritical: ":red_circle:",
+}
+
+// Threshold is reached by a branch that is ahead by at least Ahead commits or has been ahead for at least Days days
+// a criterion set to 0 is not checked, days are only known when state is kept between runs
+type Threshold struct {
+	Ahead int `json:"ahead" yaml:"ahead"`
+	Days  int `json:"days" yaml:"days"`
+}
+
+// Reached reports whether a branch that is ahead by the supplied commits for the supplied days reaches the threshold
+func (t Threshold) Reached(ahead, days int) bool {
+	return (t.Ahead > 0 && ahead >= t.Ahead) || (t.Days > 0 && days >= t.Days)
+}
+
+// IsZero reports whether no criterion of the threshold is set
+func (t Threshold) IsZero() bool {
+	return t.Ahead == 0 && t.Days == 0
+}
+
+// SeverityThresholds classify a branch that is ahead as warning or critical, branches reaching neither are info
+type SeverityThresholds struct {
+	Warning  Threshold `json:"warning" yaml:"warning"`
+	Critical Threshold `json:"critical" yaml:"critical"`
+}
+
+// IsZero reports whether no threshold is set
+func (s SeverityThresholds) IsZero() bool {
+	return s.Warning.IsZero() && s.Critical.IsZero()
+}
+
+// Level returns the severity of a branch that is ahead by the supplied commits for the supplied days
+func (s SeverityThresholds) Level(ahead, days int) string {
+	switch {
+	case s.Critical.Reached(ahead, days):
+		return SeverityCritical
+	case s.Warning.Reached(ahead, days):
+		return SeverityWarning
+	}
+	return SeverityInfo
+}
+
+func (s SeverityThresholds) validate(errs *ValidationError, path string) {
+	for i, threshold := range []Threshold{s.Warning, s.Critical} {
+		level := severityLevels[i+1]
+		if threshold.Ahead < 0 {
+			errs.add(path+"."+level+".ahead", "must not be negative, got %d", threshold.Ahead)
+		}
+		if threshold.Days < 0 {
+			errs.add(path+"."+level+".days", "must not be negative, got %d", threshold.Days)
+		}
+	}
+
+	if s.Warning.Ahead > 0 && s.Critical.Ahead > 0 && s.Critical.Ahead < s.Warning.Ahead {
+		errs.add(path+".critical.ahead", "must not be below the warning threshold %d, got %d", s.Warning.Ahead, s.Critical.Ahead)
+	}
+	if s.Warning.Days > 0 && s.Critical.Days > 0 && s.Critical.Days < s.Warning.Days {
+		errs.add(path+".critical.days", "must not be below the warning threshold %d, got %d", s.Warning.Days, s.Critical.Days)
+	}
+}
+
+// Severity classifies the branches that are ahead as info, warning or critical
+// the thresholds of a repo replace those of the rule, which replace the default thresholds
+type Severity struct {
+	SeverityThresholds `yaml:",inline"`
+	// Repos are the thresholds of single repos, written as repo or owner/repo
+	Repos map[string]SeverityThresholds `json:"repos" yaml:"repos"`
+	// Minimum hides the branches below this level from the report, it defaults to info
+	Minimum string `json:"minimum" yaml:"minimum"`
+	// Emoji prefix the messages of each level, they replace the default emoji
+	Emoji map[string]string `json:"emoji" yaml:"emoji"`
+}
+
+// ThresholdsFor returns the thresholds applying to the supplied repo and rule
+func (s *Severity) ThresholdsFor(r Rule, owner, repo string) SeverityThresholds {
+	if thresholds, ok := s.Repos[owner+"/"+repo]; ok {
+		return thresholds
+	}
+
+	if thresholds, ok := s.Repos[repo]; ok {
+		return thresholds
+	}
+
+	if r.Severity != nil {
+		return *r.Severity
+	}
+	return s.SeverityThresholds
+}
+
+// Shown reports whether branches of the supplied level are shown in the report
+func (s *Severity) Shown(level string) bool {
+	return severityRank(level) >= severityRank(s.Minimum)
+}
+
+// EmojiFor returns the emoji prefixing the messages of the supplied level
+func (s *Severity) EmojiFor(level string) string {
+	if emoji, ok := s.Emoji[level]; ok {
+		return emoji
+	}
+	return defaultSeverityEmoji[level]
+}
+
+func (s *Severity) validate(errs *ValidationError, path string) {
+	s.SeverityThresholds.validate(errs, path)
+
+	var repos []string
+	for repo := range s.Repos {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+
+	for _, repo := range repos {
+		s.Repos[repo].validate(errs, fmt.Sprintf("%s.repos[%q]", path, repo))
+	}
+
+	// an empty minimum shows every level
+	if !oneOf(s.Minimum, "", SeverityInfo, SeverityWarning, SeverityCritical) {
+		errs.add(path+".minimum", "must be one of %s, %s or %s, got %q", SeverityInfo, SeverityWarning, SeverityCritical, s.Minimum)
+	}
+
+	var levels []string
+	for level := range s.Emoji {
+		levels = append(levels, level)
+	}
+	sort.Strings(levels)
+
+	for _, level := range levels {
+		if !oneOf(level, severityLevels...) {
+			errs.add(fmt.Sprintf("%s.emoji[%q]", path, level), "must be one of %s, %s or %s", SeverityInfo, SeverityWarning, SeverityCritical)
+		}
+	}
+}
+
+// severityRank orders the severity levels, unknown levels rank as info
+func severityRank(level string) int {
+	for i, l := range severityLevels {
+		if l == level {
+			return i
+		}
+	}
+	return 0
+}
@@ -0,0 +1,88 @@
+package config
+
+import "testing"
+
+func TestSeverity_Level(t *testing.T) {
+	severity := &Severity{
+		SeverityThresholds: SeverityThresholds{Warning: Threshold{Ahead: 5, Days: 7}, Critical: Threshold{Ahead: 20, Days: 14}},
+		Repos:              map[string]SeverityThresholds{"org/legacy": {Critical: Threshold{Days: 60}}, "api": {Warning: Threshold{Ahead: 2}}},
+	}
+	releases := Rule{Name: "release", Severity: &SeverityThresholds{Critical: Threshold{Ahead: 3}}}
+
+	tests := []struct {
+		name  string
+		rule  Rule
+		repo  string
+		ahead int
+		days  int
+		want  string
+	}{
+		{
+			name:  "Test info path",
+			repo:  "test",
+			ahead: 1,
+			want:  SeverityInfo,
+		},
+		{
+			name:  "Test warning by count path",
+			repo:  "test",
+			ahead: 5,
+			want:  SeverityWarning,
+		},
+		{
+			name:  "Test critical by age path",
+			repo:  "test",
+			ahead: 1,
+			days:  14,
+			want:  SeverityCritical,
+		},
+		{
+			name:  "Test rule thresholds path",
+			rule:  releases,
+			repo:  "test",
+			ahead: 3,
+			want:  SeverityCritical,
+		},
+		{
+			name:  "Test owner and repo thresholds path",
+			rule:  releases,
+			repo:  "legacy",
+			ahead: 30,
+			days:  30,
+			want:  SeverityInfo,
+		},
+		{
+			name:  "Test repo thresholds path",
+			repo:  "api",
+			ahead: 2,
+			want:  SeverityWarning,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := severity.ThresholdsFor(tt.rule, "org", tt.repo).Level(tt.ahead, tt.days); got != tt.want {
+				t.Errorf("Severity level = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeverity_Shown(t *testing.T) {
+	severity := &Severity{Minimum: SeverityWarning, Emoji: map[string]string{SeverityCritical: ":fire:"}}
+
+	if severity.Shown(SeverityInfo) || !severity.Shown(SeverityWarning) || !severity.Shown(SeverityCritical) {
+		t.Errorf("Severity.Shown() does not hide the levels below %s", severity.Minimum)
+	}
+
+	if !(&Severity{}).Shown(SeverityInfo) {
+		t.Errorf("Severity.Shown() hides info without a minimum")
+	}
+
+	if got := severity.EmojiFor(SeverityCritical); got != ":fire:" {
+		t.Errorf("Severity.EmojiFor() = %v, want the configured emoji", got)
+	}
+
+	if got := severity.EmojiFor(SeverityWarning); got != ":warning:" {
+		t.Errorf("Severity.EmojiFor() = %v, want the default emoji", got)
+	}
+}
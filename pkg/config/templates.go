@@ -0,0 +1,32 @@
+package config
+
+import "text/template"
+
+// MessageTemplates are the text/templates used to build the reports, the default wording is used for the ones that are empty
+// the data available to each template is documented on the notification Summary, RepoHeader, BranchStatus and UpToDate types
+type MessageTemplates struct {
+	// Summary heads every report, such as "*{{.Org}} branch check summary:*"
+	Summary string `json:"summary" yaml:"summary"`
+	// Repo heads the messages of each repo, such as "*{{.Repo}}*:"
+	Repo string `json:"repo" yaml:"repo"`
+	// Branch describes each branch that is ahead, the template of a rule replaces it for the branches of the rule
+	Branch string `json:"branch" yaml:"branch"`
+	// UpToDate describes a repo whose branches are all up to date, such as "up to date with {{.Base}}"
+	UpToDate string `json:"up_to_date" yaml:"up_to_date"`
+}
+
+func (m MessageTemplates) validate(errs *ValidationError, path string) {
+	for _, t := range []struct {
+		name string
+		text string
+	}{
+		{"summary", m.Summary},
+		{"repo", m.Repo},
+		{"branch", m.Branch},
+		{"up_to_date", m.UpToDate},
+	} {
+		if _, err := template.New(t.name).Parse(t.text); err != nil {
+			errs.add(path+"."+t.name, "%v", err)
+		}
+	}
+}
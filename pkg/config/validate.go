@@ -0,0 +1,238 @@
+package config
+
+import (
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/aaron-vaz/github-branch-bot/pkg/state"
+)
+
+// FieldError is a single problem found in the configuration
+type FieldError struct {
+	// Field is the path of the setting in the configuration document, such as rules[0].head[1]
+	Field   string
+	Message string
+}
+
+func (e FieldError) String() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError lists every problem found in the configuration
+type ValidationError struct {
+	Problems []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, 0, len(e.Problems))
+	for _, problem := range e.Problems {
+		problems = append(problems, "  "+problem.String())
+	}
+
+	return "invalid configuration:\n" + strings.Join(problems, "\n")
+}
+
+func (e *ValidationError) add(field, format string, args ...interface{}) {
+	e.Problems = append(e.Problems, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate checks every setting of the params and returns a ValidationError listing all the problems found
+// the github credentials and an organisation or owners are always required, other settings can be required by passing their names
+func (p *Params) Validate(required ...string) error {
+	errs := &ValidationError{}
+	p.validate(errs, required)
+
+	if len(errs.Problems) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (p *Params) validate(errs *ValidationError, required []string) {
+	if p.GithubAppID == "" {
+		requireSetting(errs, "github_token", "GITHUB_TOKEN", p.GithubToken)
+	} else {
+		requireSetting(errs, "github_app_private_key", "GITHUB_APP_PRIVATE_KEY", p.GithubAppPrivateKey)
+		if block, _ := pem.Decode([]byte(p.GithubAppPrivateKey)); p.GithubAppPrivateKey != "" && block == nil {
+			errs.add("github_app_private_key", "must be a pem encoded private key")
+		}
+	}
+
+	if p.GithubAppInstallationID < 0 {
+		errs.add("github_app_installation_id", "must not be negative, got %d", p.GithubAppInstallationID)
+	}
+
+	for i, repository := range p.Repositories {
+		if _, _, ok := splitRepository(repository); !ok {
+			errs.add(fmt.Sprintf("repositories[%d]", i), "must be written as owner/repo, got %q", repository)
+		}
+	}
+
+	if len(p.GithubOwners) == 0 && len(p.Repositories) == 0 {
+		requireSetting(errs, "github_organisation", "GITHUB_ORGANISATION", p.GithubOrganization)
+	}
+
+	for i, owner := range p.GithubOwners {
+		owner.validate(errs, fmt.Sprintf("owners[%d]", i))
+	}
+
+	// the teams of the github organisation are not used when owners are configured, each owner lists its own teams
+	if len(p.Teams) > 0 && len(p.GithubOwners) > 0 {
+		errs.add("teams", "cannot be used with owners, set the teams of each owner instead")
+	}
+
+	validateTeams(errs, "teams", p.Teams)
+
+	for _, setting := range required {
+		switch setting {
+		case WebhookURLSetting:
+			requireSetting(errs, WebhookURLSetting, "WEBHOOK_URL", p.WebhookURL)
+		case SlackCommandTokenSetting:
+			requireSetting(errs, SlackCommandTokenSetting, "SLACK_COMMAND_TOKEN", p.SlackCommandToken)
+		}
+	}
+
+	validateURL(errs, "github_base_url", p.GithubBaseURL)
+	if p.WebhookURL != "" {
+		validateURL(errs, "webhook_url", p.WebhookURL)
+	}
+
+	if p.Version != 0 && p.Version != SchemaVersion {
+		errs.add("version", "unsupported schema version %d, the current schema version is %d", p.Version, SchemaVersion)
+	}
+
+	if !oneOf(p.BaseBranchMode, BaseModeFixed, BaseModeRepo) {
+		errs.add("base_branch_mode", "must be one of %s or %s, got %q", BaseModeFixed, BaseModeRepo, p.BaseBranchMode)
+	}
+
+	if !oneOf(p.StaleBranchCleanup, CleanupOff, CleanupDryRun, CleanupDelete) {
+		errs.add("stale_branch_cleanup", "must be one of %s, %s or %s, got %q", CleanupOff, CleanupDryRun, CleanupDelete, p.StaleBranchCleanup)
+	}
+
+	// an empty report mode sends a combined report
+	if !oneOf(p.ReportMode, "", ReportCombined, ReportPerOwner) {
+		errs.add("report_mode", "must be one of %s or %s, got %q", ReportCombined, ReportPerOwner, p.ReportMode)
+	}
+
+	if p.StaleBranchDays < 0 {
+		errs.add("stale_branch_days", "must not be negative, got %d", p.StaleBranchDays)
+	}
+
+	if p.CommitStatusThreshold < 0 {
+		errs.add("commit_status_threshold", "must not be negative, got %d", p.CommitStatusThreshold)
+	}
+
+	p.RepoFilter.validate(errs, "repo_filter")
+	p.validateMentions(errs)
+
+	if p.StateStore != "" {
+		if _, err := state.Open(p.StateStore); err != nil {
+			errs.add("state_store", "%v", err)
+		}
+	}
+
+	p.validateNotifyMode(errs)
+	p.validateEscalations(errs)
+	p.Severity.validate(errs, "severity")
+	p.Templates.validate(errs, "templates")
+	p.validateUpToDateMode(errs)
+
+	if len(p.BranchRules) == 0 {
+		validatePatterns(errs, "head_branch_prefixes", p.HeadBranchPrefixes)
+		validatePatterns(errs, "head_branch_excludes", p.HeadBranchExcludes)
+		validateBaseOverrides(errs, "base_overrides", p.BaseOverrides, p.HeadBranchPrefixes)
+	}
+
+	for i, rule := range p.BranchRules {
+		rule.validate(errs, fmt.Sprintf("rules[%d]", i))
+	}
+}
+
+func (p *Params) validateUpToDateMode(errs *ValidationError) {
+	// an empty up to date mode lists every repo
+	if !oneOf(p.UpToDateMode, "", UpToDateList, UpToDateOmit, UpToDateThread, UpToDateAttachment) {
+		errs.add("up_to_date_mode", "must be one of %s, %s, %s or %s, got %q", UpToDateList, UpToDateOmit, UpToDateThread, UpToDateAttachment, p.UpToDateMode)
+	}
+
+	if p.UpToDateMode != UpToDateThread {
+		return
+	}
+
+	if p.SlackBotToken == "" {
+		errs.add("up_to_date_mode", "%s needs slack_bot_token to be set", UpToDateThread)
+	}
+
+	if p.SlackChannel == "" {
+		errs.add("up_to_date_mode", "%s needs slack_channel to be set", UpToDateThread)
+	}
+}
+
+func (p *Params) validateNotifyMode(errs *ValidationError) {
+	// an empty notify mode reports every branch
+	if !oneOf(p.NotifyMode, "", NotifyFull, NotifyDelta) {
+		errs.add("notify_mode", "must be one of %s or %s, got %q", NotifyFull, NotifyDelta, p.NotifyMode)
+	}
+
+	if p.NotifyMode != NotifyDelta {
+		return
+	}
+
+	if p.StateStore == "" {
+		errs.add("notify_mode", "%s needs state_store to be set", NotifyDelta)
+	}
+
+	if p.DeltaThreshold < 1 {
+		errs.add("delta_threshold", "must be at least 1, got %d", p.DeltaThreshold)
+	}
+
+	if _, ok := p.DigestWeekday(); !ok && !strings.EqualFold(p.DigestDay, "none") {
+		errs.add("digest_day", "must be a day of the week or none, got %q", p.DigestDay)
+	}
+}
+
+func requireSetting(errs *ValidationError, field, env, value string) {
+	if strings.TrimSpace(value) == "" {
+		errs.add(field, "is required, set %s or %s in the config document", env, field)
+	}
+}
+
+func validateURL(errs *ValidationError, field, value string) {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs.add(field, "must be an absolute http or https url, got %q", value)
+	}
+}
+
+// validateBaseOverrides checks that every override names a base branch and overrides one of the head patterns
+func validateBaseOverrides(errs *ValidationError, field string, overrides map[string]string, heads []string) {
+	patterns := make([]string, 0, len(overrides))
+	for pattern := range overrides {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	for _, pattern := range patterns {
+		path := fmt.Sprintf("%s[%q]", field, pattern)
+
+		if strings.TrimSpace(overrides[pattern]) == "" {
+			errs.add(path, "must name a base branch, use pattern=base")
+		}
+
+		if !oneOf(pattern, heads...) {
+			errs.add(path, "does not match any head pattern")
+		}
+	}
+}
+
+func oneOf(value string, allowed ...string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	return false
+}
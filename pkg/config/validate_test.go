@@ -0,0 +1,348 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParams_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   *Params
+		required []string
+		wantErr  string
+	}{
+		{
+			name: "Test Happy path",
+			params: &Params{
+				GithubBaseURL:      DefaultGithubBaseURL,
+				GithubToken:        "token",
+				GithubOrganization: "org",
+				BaseBranchMode:     BaseModeFixed,
+				StaleBranchCleanup: CleanupOff,
+				HeadBranchPrefixes: []string{"release/*"},
+			},
+		},
+		{
+			name: "Test every problem is reported path",
+			params: &Params{
+				GithubBaseURL:      DefaultGithubBaseURL,
+				GithubToken:        "token",
+				GithubOrganization: "org",
+				Version:            3,
+				BaseBranchMode:     "sometimes",
+				StaleBranchCleanup: "always",
+				StaleBranchDays:    -1,
+				HeadBranchPrefixes: []string{"master", "re:("},
+			},
+			wantErr: "invalid configuration:\n" +
+				"  version: unsupported schema version 3, the current schema version is 1\n" +
+				"  base_branch_mode: must be one of fixed or repo, got \"sometimes\"\n" +
+				"  stale_branch_cleanup: must be one of off, dry-run or delete, got \"always\"\n" +
+				"  stale_branch_days: must not be negative, got -1\n" +
+				"  head_branch_prefixes[1]: invalid branch patterns: pattern \"re:(\": error parsing regexp: missing closing ): `(`",
+		},
+		{
+			name: "Test required settings path",
+			params: &Params{
+				GithubBaseURL:      "ftp://github.example.com",
+				GithubToken:        " ",
+				BaseBranchMode:     BaseModeFixed,
+				StaleBranchCleanup: CleanupOff,
+			},
+			required: []string{WebhookURLSetting, SlackCommandTokenSetting},
+			wantErr: "invalid configuration:\n" +
+				"  github_token: is required, set GITHUB_TOKEN or github_token in the config document\n" +
+				"  github_organisation: is required, set GITHUB_ORGANISATION or github_organisation in the config document\n" +
+				"  webhook_url: is required, set WEBHOOK_URL or webhook_url in the config document\n" +
+				"  slack_command_token: is required, set SLACK_COMMAND_TOKEN or slack_command_token in the config document\n" +
+				"  github_base_url: must be an absolute http or https url, got \"ftp://github.example.com\"",
+		},
+		{
+			name: "Test github app path",
+			params: &Params{
+				GithubBaseURL:           DefaultGithubBaseURL,
+				GithubAppID:             "1234",
+				GithubAppPrivateKey:     "not a key",
+				GithubAppInstallationID: -1,
+				GithubOrganization:      "org",
+				BaseBranchMode:          BaseModeFixed,
+				StaleBranchCleanup:      CleanupOff,
+			},
+			wantErr: "invalid configuration:\n" +
+				"  github_app_private_key: must be a pem encoded private key\n" +
+				"  github_app_installation_id: must not be negative, got -1",
+		},
+		{
+			name: "Test github app without private key path",
+			params: &Params{
+				GithubBaseURL:      DefaultGithubBaseURL,
+				GithubAppID:        "1234",
+				GithubOrganization: "org",
+				BaseBranchMode:     BaseModeFixed,
+				StaleBranchCleanup: CleanupOff,
+			},
+			wantErr: "invalid configuration:\n" +
+				"  github_app_private_key: is required, set GITHUB_APP_PRIVATE_KEY or github_app_private_key in the config document",
+		},
+		{
+			name: "Test owners path",
+			params: &Params{
+				GithubBaseURL:      DefaultGithubBaseURL,
+				GithubToken:        "token",
+				BaseBranchMode:     BaseModeFixed,
+				StaleBranchCleanup: CleanupOff,
+				ReportMode:         "daily",
+				GithubOwners: []Owner{
+					{Name: "org"},
+					{Name: " ", Type: "team"},
+					{Name: "someone", Type: OwnerUser, Rules: []Rule{{Name: "release"}}, Teams: []Team{{Slug: "payments"}}},
+				},
+				Teams: []Team{{Slug: " "}, {Slug: "platform", WebhookURL: "hooks.slack.com"}},
+			},
+			wantErr: "invalid configuration:\n" +
+				"  owners[1].name: is required\n" +
+				"  owners[1].type: must be one of org or user, got \"team\"\n" +
+				"  owners[2].rules[0].head: at least one head pattern is required\n" +
+				"  owners[2].teams: only organisations have teams\n" +
+				"  teams: cannot be used with owners, set the teams of each owner instead\n" +
+				"  teams[0].slug: is required\n" +
+				"  teams[1].webhook_url: must be an absolute http or https url, got \"hooks.slack.com\"\n" +
+				"  report_mode: must be one of combined or per-owner, got \"daily\"",
+		},
+		{
+			name: "Test repositories path",
+			params: &Params{
+				GithubBaseURL:      DefaultGithubBaseURL,
+				GithubToken:        "token",
+				BaseBranchMode:     BaseModeFixed,
+				StaleBranchCleanup: CleanupOff,
+				Repositories:       []string{"org/api", "api", "org/api/web", "/api"},
+			},
+			wantErr: "invalid configuration:\n" +
+				"  repositories[1]: must be written as owner/repo, got \"api\"\n" +
+				"  repositories[2]: must be written as owner/repo, got \"org/api/web\"\n" +
+				"  repositories[3]: must be written as owner/repo, got \"/api\"",
+		},
+		{
+			name: "Test repo filter path",
+			params: &Params{
+				GithubBaseURL:      DefaultGithubBaseURL,
+				GithubToken:        "token",
+				GithubOrganization: "org",
+				BaseBranchMode:     BaseModeFixed,
+				StaleBranchCleanup: CleanupOff,
+				RepoFilter: RepoFilter{
+					Visibility:       []string{"private", "secret"},
+					Names:            []string{"re:(", "!sandbox-*"},
+					PushedWithinDays: -90,
+				},
+			},
+			wantErr: "invalid configuration:\n" +
+				"  repo_filter.names[0]: invalid branch patterns: pattern \"re:(\": error parsing regexp: missing closing ): `(`\n" +
+				"  repo_filter.visibility[1]: must be one of public, private or internal, got \"secret\"\n" +
+				"  repo_filter.pushed_within_days: must not be negative, got -90",
+		},
+		{
+			name: "Test mentions and state path",
+			params: &Params{
+				GithubBaseURL:      DefaultGithubBaseURL,
+				GithubToken:        "token",
+				GithubOrganization: "org",
+				BaseBranchMode:     BaseModeFixed,
+				StaleBranchCleanup: CleanupOff,
+				Mentions:           []string{MentionAuthors, "reviewers"},
+				SlackUsers:         map[string]string{"octocat": "U123", "someone": " "},
+				SlackAPIURL:        "slack.com/api",
+				StateStore:         "redis://localhost",
+			},
+			wantErr: "invalid configuration:\n" +
+				"  mentions[1]: must be one of codeowners or authors, got \"reviewers\"\n" +
+				"  slack_users[\"someone\"]: must name a slack id, use login=id\n" +
+				"  slack_api_url: must be an absolute http or https url, got \"slack.com/api\"\n" +
+				"  state_store: unknown state store \"redis://localhost\", use one of memory, file, s3 or dynamodb",
+		},
+		{
+			name: "Test delta notify mode path",
+			params: &Params{
+				GithubBaseURL:      DefaultGithubBaseURL,
+				GithubToken:        "token",
+				GithubOrganization: "org",
+				BaseBranchMode:     BaseModeFixed,
+				StaleBranchCleanup: CleanupOff,
+				NotifyMode:         NotifyDelta,
+				DigestDay:          "someday",
+			},
+			wantErr: "invalid configuration:\n" +
+				"  notify_mode: delta needs state_store to be set\n" +
+				"  delta_threshold: must be at least 1, got 0\n" +
+				"  digest_day: must be a day of the week or none, got \"someday\"",
+		},
+		{
+			name: "Test escalation problems path",
+			params: &Params{
+				GithubBaseURL:      DefaultGithubBaseURL,
+				GithubToken:        "token",
+				GithubOrganization: "org",
+				BaseBranchMode:     BaseModeFixed,
+				StaleBranchCleanup: CleanupOff,
+				Escalations: []Escalation{
+					{Days: 7, Mention: true},
+					{Days: 3, WebhookURL: "hooks.slack.com"},
+					{Days: 14},
+				},
+			},
+			wantErr: "invalid configuration:\n" +
+				"  escalations: need state_store to be set\n" +
+				"  escalations[1].days: must be more than the days of the previous escalation, got 3\n" +
+				"  escalations[1].webhook_url: must be an absolute http or https url, got \"hooks.slack.com\"\n" +
+				"  escalations[2]: must mention, post to a webhook_url or open an issue",
+		},
+		{
+			name: "Test commit status threshold path",
+			params: &Params{
+				GithubBaseURL:         DefaultGithubBaseURL,
+				GithubToken:           "token",
+				GithubOrganization:    "org",
+				BaseBranchMode:        BaseModeFixed,
+				StaleBranchCleanup:    CleanupOff,
+				CommitStatus:          true,
+				CommitStatusThreshold: -1,
+			},
+			wantErr: "invalid configuration:\n" +
+				"  commit_status_threshold: must not be negative, got -1",
+		},
+		{
+			name: "Test severity problems path",
+			params: &Params{
+				GithubBaseURL:      DefaultGithubBaseURL,
+				GithubToken:        "token",
+				GithubOrganization: "org",
+				BaseBranchMode:     BaseModeFixed,
+				StaleBranchCleanup: CleanupOff,
+				Severity: Severity{
+					SeverityThresholds: SeverityThresholds{Warning: Threshold{Ahead: 10, Days: -1}, Critical: Threshold{Ahead: 5}},
+					Repos:              map[string]SeverityThresholds{"api": {Critical: Threshold{Days: -7}}},
+					Minimum:            "urgent",
+					Emoji:              map[string]string{"error": ":x:"},
+				},
+				BranchRules: []Rule{
+					{Name: "release", Head: []string{"release/*"}, Severity: &SeverityThresholds{Warning: Threshold{Days: 14}, Critical: Threshold{Days: 7}}},
+				},
+			},
+			wantErr: "invalid configuration:\n" +
+				"  severity.warning.days: must not be negative, got -1\n" +
+				"  severity.critical.ahead: must not be below the warning threshold 10, got 5\n" +
+				"  severity.repos[\"api\"].critical.days: must not be negative, got -7\n" +
+				"  severity.minimum: must be one of info, warning or critical, got \"urgent\"\n" +
+				"  severity.emoji[\"error\"]: must be one of info, warning or critical\n" +
+				"  rules[0].severity.critical.days: must not be below the warning threshold 14, got 7",
+		},
+		{
+			name: "Test template problems path",
+			params: &Params{
+				GithubBaseURL:      DefaultGithubBaseURL,
+				GithubToken:        "token",
+				GithubOrganization: "org",
+				BaseBranchMode:     BaseModeFixed,
+				StaleBranchCleanup: CleanupOff,
+				Templates:          MessageTemplates{Summary: "*{{.Org}}*", Repo: "{{.Repo", UpToDate: "{{end}}"},
+			},
+			wantErr: "invalid configuration:\n" +
+				"  templates.repo: template: repo:1: unclosed action\n" +
+				"  templates.up_to_date: template: up_to_date:1: unexpected {{end}}",
+		},
+		{
+			name: "Test up to date mode path",
+			params: &Params{
+				GithubBaseURL:      DefaultGithubBaseURL,
+				GithubToken:        "token",
+				GithubOrganization: "org",
+				BaseBranchMode:     BaseModeFixed,
+				StaleBranchCleanup: CleanupOff,
+				UpToDateMode:       "hidden",
+			},
+			wantErr: "invalid configuration:\n" +
+				"  up_to_date_mode: must be one of list, omit, thread or attachment, got \"hidden\"",
+		},
+		{
+			name: "Test up to date thread path",
+			params: &Params{
+				GithubBaseURL:      DefaultGithubBaseURL,
+				GithubToken:        "token",
+				GithubOrganization: "org",
+				BaseBranchMode:     BaseModeFixed,
+				StaleBranchCleanup: CleanupOff,
+				UpToDateMode:       UpToDateThread,
+			},
+			wantErr: "invalid configuration:\n" +
+				"  up_to_date_mode: thread needs slack_bot_token to be set\n" +
+				"  up_to_date_mode: thread needs slack_channel to be set",
+		},
+		{
+			name: "Test rule problems path",
+			params: &Params{
+				GithubBaseURL:      DefaultGithubBaseURL,
+				GithubToken:        "token",
+				GithubOrganization: "org",
+				BaseBranchMode:     BaseModeFixed,
+				StaleBranchCleanup: CleanupOff,
+				HeadBranchPrefixes: []string{"re:("},
+				BranchRules: []Rule{
+					{Name: "release", Head: []string{"release/*"}, Exclude: []string{"re:["}, Threshold: -1, BaseOverrides: map[string]string{"hotfix/*": "main"}},
+					{Name: "empty", Template: "{{.Head"},
+				},
+			},
+			wantErr: "invalid configuration:\n" +
+				"  rules[0].exclude[0]: invalid branch patterns: pattern \"re:[\": error parsing regexp: missing closing ]: `[`\n" +
+				"  rules[0].base_overrides[\"hotfix/*\"]: does not match any head pattern\n" +
+				"  rules[0].threshold: must not be negative, got -1\n" +
+				"  rules[1].head: at least one head pattern is required\n" +
+				"  rules[1].template: template: empty:1: unclosed action",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.params.Validate(tt.required...)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Params.Validate() unexpected error = %v", err)
+			}
+
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("Params.Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParams_Validate_Document(t *testing.T) {
+	os.Setenv("CONFIG_FILE", filepath.Join(testResources, "invalid.yaml"))
+	defer clearEnvs()
+
+	params := &Params{GithubBaseURL: DefaultGithubBaseURL, BaseBranchMode: BaseModeFixed, StaleBranchCleanup: CleanupOff}
+	if err := params.loadDocument(); err != nil {
+		t.Fatalf("Params.loadDocument() unexpected error = %v", err)
+	}
+
+	err, ok := params.Validate().(*ValidationError)
+	if !ok {
+		t.Fatalf("Params.Validate() did not return a ValidationError, got = %v", err)
+	}
+
+	var fields []string
+	for _, problem := range err.Problems {
+		fields = append(fields, problem.Field)
+	}
+
+	want := []string{"github_token", "github_organisation", "version", "base_branch_mode", "stale_branch_cleanup", "rules[0].head[0]", "rules[0].template", "rules[1].head"}
+	if len(fields) != len(want) {
+		t.Fatalf("Params.Validate() reported fields %v, want %v", fields, want)
+	}
+
+	for i := range want {
+		if fields[i] != want[i] {
+			t.Errorf("Params.Validate() reported fields %v, want %v", fields, want)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aaron-vaz/golang-utils/pkg/ioutils"
+)
+
+const (
+	getOrgInstallationPath      = "/orgs/%s/installation"
+	getUserInstallationPath     = "/users/%s/installation"
+	getRepoInstallationPath     = "/repos/%s/%s/installation"
+	createInstallationTokenPath = "/app/installations/%d/access_tokens"
+
+	bearerHeaderPrefix = "Bearer "
+
+	// github rejects app jwts that expire more than 10 minutes in the future
+	jwtLifetime = 9 * time.Minute
+	// installation tokens are refreshed this long before they expire so requests in flight do not fail
+	tokenRefreshMargin = 5 * time.Minute
+)
+
+// TokenSource supplies the token used to authenticate requests to the github api
+type TokenSource interface {
+	Token() (string, error)
+}
+
+// Installation is the struct that represents a github app installation response
+type Installation struct {
+	ID int64 `json:"id"`
+}
+
+// InstallationToken is the struct that represents a github app installation access token response
+type InstallationToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// AppTokenSource authenticates as a github app installation
+// it signs a jwt with the app private key and exchanges it for an installation access token which is cached until shortly before it expires
+type AppTokenSource struct {
+	BaseURL    string
+	AppID      string
+	PrivateKey *rsa.PrivateKey
+	// Org is used to look up the installation when InstallationID is not set
+	Org string
+	// User looks up the installation on the user account named by Org instead of the organisation
+	User bool
+	// Repo looks up the installation on the repo Org/Repo, which is found whether the repo belongs to an org or a user
+	Repo           string
+	InstallationID int64
+	*http.Client
+
+	mu    sync.Mutex
+	token InstallationToken
+	now   func() time.Time
+	// owners caches the token sources of the installations on other owners and repos
+	owners map[string]*AppTokenSource
+}
+
+// NewAppTokenSource creates a token source for the installation of the app in the supplied org
+// the private key is the pem encoded key downloaded from the github app settings
+func NewAppTokenSource(baseURL, appID string, privateKey []byte, org string, installationID int64, client *http.Client) (*AppTokenSource, error) {
+	key, err := ParsePrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AppTokenSource{
+		BaseURL:        baseURL,
+		AppID:          appID,
+		PrivateKey:     key,
+		Org:            org,
+		InstallationID: installationID,
+		Client:         client,
+	}, nil
+}
+
+// ParsePrivateKey parses a pem encoded PKCS1 or PKCS8 rsa private key
+func ParsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("github app private key is not pem encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse github app private key: %v", err)
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("github app private key is not an rsa key")
+	}
+
+	return rsaKey, nil
+}
+
+// Token returns the cached installation access token, a new one is requested when it is about to expire
+func (s *AppTokenSource) Token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token.Token != "" && s.clock().Add(tokenRefreshMargin).Before(s.token.ExpiresAt) {
+		return s.token.Token, nil
+	}
+
+	jwt, err := s.signJWT()
+	if err != nil {
+		return "", err
+	}
+
+	if s.InstallationID == 0 {
+		url := s.BaseURL + fmt.Sprintf(getOrgInstallationPath, s.Org)
+		switch {
+		case s.Repo != "":
+			url = s.BaseURL + fmt.Sprintf(getRepoInstallationPath, s.Org, s.Repo)
+		case s.User:
+			url = s.BaseURL + fmt.Sprintf(getUserInstallationPath, s.Org)
+		}
+
+		installation := &Installation{}
+		if err := s.sendAppRequest(http.MethodGet, url, jwt, installation); err != nil {
+			return "", err
+		}
+		s.InstallationID = installation.ID
+	}
+
+	token := InstallationToken{}
+	if err := s.sendAppRequest(http.MethodPost, s.BaseURL+fmt.Sprintf(createInstallationTokenPath, s.InstallationID), jwt, &token); err != nil {
+		return "", err
+	}
+
+	s.token = token
+	return token.Token, nil
+}
+
+// ForOwner returns the token source for the installation of the app on the supplied org or user account
+// the sources are kept so their tokens are reused until they expire
+func (s *AppTokenSource) ForOwner(owner string, user bool) *AppTokenSource {
+	if owner == s.Org && user == s.User {
+		return s
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := fmt.Sprintf("%s:%t", owner, user)
+	if source, ok := s.owners[key]; ok {
+		return source
+	}
+
+	if s.owners == nil {
+		s.owners = make(map[string]*AppTokenSource)
+	}
+
+	source := &AppTokenSource{BaseURL: s.BaseURL, AppID: s.AppID, PrivateKey: s.PrivateKey, Org: owner, User: user, Client: s.Client, now: s.now}
+	s.owners[key] = source
+	return source
+}
+
+// ForRepository returns the token source for the installation of the app on the supplied repo
+// repos of the configured org use its installation, the sources of other repos are kept so their tokens are reused until they expire
+func (s *AppTokenSource) ForRepository(owner, repo string) *AppTokenSource {
+	if owner == s.Org && !s.User && s.Repo == "" {
+		return s
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := owner + "/" + repo
+	if source, ok := s.owners[key]; ok {
+		return source
+	}
+
+	if s.owners == nil {
+		s.owners = make(map[string]*AppTokenSource)
+	}
+
+	source := &AppTokenSource{BaseURL: s.BaseURL, AppID: s.AppID, PrivateKey: s.PrivateKey, Org: owner, Repo: repo, Client: s.Client, now: s.now}
+	s.owners[key] = source
+	return source
+}
+
+// signJWT creates the RS256 signed jwt that identifies the app
+func (s *AppTokenSource) signJWT() (string, error) {
+	now := s.clock()
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		// backdated to allow for clock drift between the lambda and github
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(jwtLifetime).Unix(),
+		"iss": s.AppID,
+	})
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.PrivateKey, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", fmt.Errorf("could not sign github app jwt: %v", err)
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func (s *AppTokenSource) sendAppRequest(method, url, jwt string, response interface{}) error {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Add(authorizationHeader, bearerHeaderPrefix+jwt)
+	req.Header.Add(contentTypeHeader, jsonMediaType)
+
+	res, err := s.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer ioutils.Close(res.Body)
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("github app %s %s failed with status %s", method, url, res.Status)
+	}
+
+	return json.Unmarshal(body, response)
+}
+
+func (s *AppTokenSource) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
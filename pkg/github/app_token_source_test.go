@@ -0,0 +1,303 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeTokenServer is a local github that issues installation tokens to requests carrying a valid app jwt
+type fakeTokenServer struct {
+	*httptest.Server
+	key          *rsa.PrivateKey
+	now          time.Time
+	tokensIssued int
+}
+
+func newFakeTokenServer(t *testing.T, key *rsa.PrivateKey) *fakeTokenServer {
+	f := &fakeTokenServer{key: key, now: time.Date(2021, 11, 1, 12, 0, 0, 0, time.UTC)}
+
+	f.Server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if err := f.verifyJWT(req.Header.Get(authorizationHeader)); err != nil {
+			t.Errorf("fake token endpoint received an invalid jwt: %v", err)
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case req.Method == http.MethodGet && (req.URL.Path == "/orgs/org/installation" || req.URL.Path == "/users/someone/installation" ||
+			req.URL.Path == "/repos/someone/dotfiles/installation"):
+			rw.Write([]byte(`{"id": 42}`))
+
+		case req.Method == http.MethodPost && req.URL.Path == "/app/installations/42/access_tokens":
+			f.tokensIssued++
+			json.NewEncoder(rw).Encode(InstallationToken{
+				Token:     fmt.Sprintf("ghs_token%d", f.tokensIssued),
+				ExpiresAt: f.now.Add(time.Hour),
+			})
+
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	return f
+}
+
+func (f *fakeTokenServer) verifyJWT(header string) error {
+	if !strings.HasPrefix(header, bearerHeaderPrefix) {
+		return fmt.Errorf("authorization header %q is not a bearer token", header)
+	}
+
+	parts := strings.Split(strings.TrimPrefix(header, bearerHeaderPrefix), ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("jwt has %d parts", len(parts))
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(&f.key.PublicKey, crypto.SHA256, hash[:], signature); err != nil {
+		return err
+	}
+
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	claims := struct {
+		IssuedAt  int64  `json:"iat"`
+		ExpiresAt int64  `json:"exp"`
+		Issuer    string `json:"iss"`
+	}{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return err
+	}
+
+	if claims.Issuer != "1234" {
+		return fmt.Errorf("jwt issuer = %s, want 1234", claims.Issuer)
+	}
+
+	if claims.IssuedAt > f.now.Unix() || claims.ExpiresAt > f.now.Add(10*time.Minute).Unix() {
+		return fmt.Errorf("jwt is valid from %d to %d, which github rejects", claims.IssuedAt, claims.ExpiresAt)
+	}
+
+	return nil
+}
+
+func TestAppTokenSource_Token(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := newFakeTokenServer(t, key)
+	defer server.Close()
+
+	source, err := NewAppTokenSource(server.URL, "1234", pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), "org", 0, server.Client())
+	if err != nil {
+		t.Fatalf("NewAppTokenSource() unexpected error = %v", err)
+	}
+
+	start := server.now
+	source.now = func() time.Time { return server.now }
+
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		want    string
+	}{
+		{
+			name: "Test new token path",
+			want: "ghs_token1",
+		},
+		{
+			name:    "Test cached token path",
+			elapsed: 30 * time.Minute,
+			want:    "ghs_token1",
+		},
+		{
+			name:    "Test token refreshed before expiry path",
+			elapsed: 56 * time.Minute,
+			want:    "ghs_token2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server.now = start.Add(tt.elapsed)
+
+			got, err := source.Token()
+			if err != nil {
+				t.Errorf("AppTokenSource.Token() unexpected error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("AppTokenSource.Token() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if source.InstallationID != 42 {
+		t.Errorf("AppTokenSource.InstallationID = %d, want 42", source.InstallationID)
+	}
+}
+
+func TestAppTokenSource_ForOwner(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := newFakeTokenServer(t, key)
+	defer server.Close()
+
+	source := &AppTokenSource{BaseURL: server.URL, AppID: "1234", PrivateKey: key, Org: "org", InstallationID: 7, Client: server.Client(), now: func() time.Time { return server.now }}
+
+	user := source.ForOwner("someone", true)
+	if user != source.ForOwner("someone", true) {
+		t.Errorf("AppTokenSource.ForOwner() did not reuse the token source of the owner")
+	}
+
+	if got, err := user.Token(); err != nil || got != "ghs_token1" {
+		t.Errorf("AppTokenSource.ForOwner().Token() = %v, %v, want ghs_token1", got, err)
+	}
+
+	if user.InstallationID != 42 {
+		t.Errorf("AppTokenSource.ForOwner().InstallationID = %d, want 42", user.InstallationID)
+	}
+}
+
+func TestAppTokenSource_ForRepository(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := newFakeTokenServer(t, key)
+	defer server.Close()
+
+	source := &AppTokenSource{BaseURL: server.URL, AppID: "1234", PrivateKey: key, Org: "org", InstallationID: 7, Client: server.Client(), now: func() time.Time { return server.now }}
+
+	if source.ForRepository("org", "repo") != source {
+		t.Errorf("AppTokenSource.ForRepository() did not use the installation of the configured org")
+	}
+
+	// the owner is not known to be an org or a user, so the installation is found through the repo
+	repo := source.ForRepository("someone", "dotfiles")
+	if repo != source.ForRepository("someone", "dotfiles") {
+		t.Errorf("AppTokenSource.ForRepository() did not reuse the token source of the repo")
+	}
+
+	if got, err := repo.Token(); err != nil || got != "ghs_token1" {
+		t.Errorf("AppTokenSource.ForRepository().Token() = %v, %v, want ghs_token1", got, err)
+	}
+
+	if repo.InstallationID != 42 {
+		t.Errorf("AppTokenSource.ForRepository().InstallationID = %d, want 42", repo.InstallationID)
+	}
+}
+
+func TestAppTokenSource_Token_Error(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	source := &AppTokenSource{BaseURL: server.URL, AppID: "1234", PrivateKey: key, InstallationID: 42, Client: server.Client()}
+	if _, err := source.Token(); err == nil {
+		t.Errorf("AppTokenSource.Token() expected an error when the token request is rejected")
+	}
+}
+
+func TestAPIService_Tokens(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokenServer := newFakeTokenServer(t, key)
+	defer tokenServer.Close()
+
+	var gotHeader string
+	apiServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		gotHeader = req.Header.Get(authorizationHeader)
+	}))
+	defer apiServer.Close()
+
+	service := &APIService{
+		BaseURL: apiServer.URL,
+		Client:  apiServer.Client(),
+		Tokens:  &AppTokenSource{BaseURL: tokenServer.URL, AppID: "1234", PrivateKey: key, InstallationID: 42, Client: tokenServer.Client(), now: func() time.Time { return tokenServer.now }},
+	}
+
+	if err := service.DeleteBranch("org", "repo", "feature"); err != nil {
+		t.Errorf("APIService.DeleteBranch() unexpected error = %v", err)
+	}
+
+	if want := tokenHeaderPrefix + "ghs_token1"; gotHeader != want {
+		t.Errorf("APIService authorization header = %v, want %v", gotHeader, want)
+	}
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkcs8, _ := x509.MarshalPKCS8PrivateKey(key)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr bool
+	}{
+		{
+			name: "Test PKCS1 path",
+			data: pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}),
+		},
+		{
+			name: "Test PKCS8 path",
+			data: pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}),
+		},
+		{
+			name:    "Test not pem path",
+			data:    []byte("not a key"),
+			wantErr: true,
+		},
+		{
+			name:    "Test invalid key path",
+			data:    pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: []byte("invalid")}),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePrivateKey(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParsePrivateKey() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr && got.N.Cmp(key.N) != 0 {
+				t.Errorf("ParsePrivateKey() returned a different key")
+			}
+		})
+	}
+}
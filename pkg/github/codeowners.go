@@ -0,0 +1,140 @@
+package github
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const getContentsPath = "/repos/%s/%s/contents/%s"
+
+// codeOwnersLocations are the paths github reads the CODEOWNERS file from, in the order it looks for them
+var codeOwnersLocations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// Contents is the struct that represents the github repository contents response for a file
+type Contents struct {
+	Content  string `json:"content"`
+	Encoding string `json:"encoding"`
+}
+
+// CodeOwners holds the rules of a CODEOWNERS file
+type CodeOwners struct {
+	rules []codeOwnersRule
+}
+
+type codeOwnersRule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// ParseCodeOwners parses the supplied CODEOWNERS file, lines with patterns that cannot be parsed are left out
+func ParseCodeOwners(data string) *CodeOwners {
+	codeOwners := &CodeOwners{}
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		pattern, err := codeOwnersPattern(fields[0])
+		if err != nil {
+			log.Printf("Skipping CODEOWNERS pattern %s: %v", fields[0], err)
+			continue
+		}
+
+		codeOwners.rules = append(codeOwners.rules, codeOwnersRule{pattern: pattern, owners: fields[1:]})
+	}
+
+	return codeOwners
+}
+
+// Owners returns the owners of the supplied file, the last matching rule wins like it does on github
+func (c *CodeOwners) Owners(file string) []string {
+	if c == nil {
+		return nil
+	}
+
+	for i := len(c.rules) - 1; i >= 0; i-- {
+		if c.rules[i].pattern.MatchString(file) {
+			return c.rules[i].owners
+		}
+	}
+
+	return nil
+}
+
+// codeOwnersPattern converts a gitignore style CODEOWNERS pattern to a regular expression matching file paths
+// patterns are anchored to the repo root when they start with or contain a slash, a pattern matching a directory matches everything in it
+func codeOwnersPattern(pattern string) (*regexp.Regexp, error) {
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+
+	var expr strings.Builder
+	if !anchored {
+		expr.WriteString("(?:.*/)?")
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			expr.WriteString("(?:.*/)?")
+			i += 2
+
+		case strings.HasPrefix(pattern[i:], "**"):
+			expr.WriteString(".*")
+			i++
+
+		case pattern[i] == '*':
+			expr.WriteString("[^/]*")
+
+		case pattern[i] == '?':
+			expr.WriteString("[^/]")
+
+		default:
+			expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+
+	return regexp.Compile("^" + expr.String() + "(?:/.*)?$")
+}
+
+// GetCodeOwners returns the CODEOWNERS rules of the supplied repo, it is nil when the repo has no CODEOWNERS file
+func (s *APIService) GetCodeOwners(owner, repo string) *CodeOwners {
+	for _, location := range codeOwnersLocations {
+		body, err := s.sendGithubRequest(http.MethodGet, s.BaseURL+fmt.Sprintf(getContentsPath, owner, repo, location), nil)
+		if err != nil {
+			continue
+		}
+
+		contents := &Contents{}
+		if err := json.Unmarshal(body, contents); err != nil || contents.Encoding != "base64" {
+			log.Printf("Could not read %s %s: unexpected response", repo, location)
+			continue
+		}
+
+		data, err := base64.StdEncoding.DecodeString(strings.Replace(contents.Content, "\n", "", -1))
+		if err != nil {
+			log.Printf("Could not decode %s %s: %v", repo, location, err)
+			continue
+		}
+
+		return ParseCodeOwners(string(data))
+	}
+
+	return nil
+}
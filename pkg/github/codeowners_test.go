@@ -0,0 +1,70 @@
+package github
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCodeOwners_Owners(t *testing.T) {
+	codeOwners := ParseCodeOwners(`# default owners
+*                  @org/platform
+
+*.go               @gopher   # inline comments are ignored
+/docs/             docs@example.com
+build/**/*.yml     @org/ci
+/cmd/bot/main.go   @octocat
+vendor/
+`)
+
+	tests := []struct {
+		name string
+		file string
+		want []string
+	}{
+		{name: "Test default owners path", file: "README.md", want: []string{"@org/platform"}},
+		{name: "Test extension in any directory path", file: "pkg/github/codeowners.go", want: []string{"@gopher"}},
+		{name: "Test anchored directory path", file: "docs/guide/setup.md", want: []string{"docs@example.com"}},
+		{name: "Test anchored directory in subdirectory path", file: "pkg/docs/setup.md", want: []string{"@org/platform"}},
+		{name: "Test double star path", file: "build/ci/jobs/test.yml", want: []string{"@org/ci"}},
+		{name: "Test last matching rule wins path", file: "cmd/bot/main.go", want: []string{"@octocat"}},
+		{name: "Test rule without owners path", file: "vendor/lib/lib.go", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := codeOwners.Owners(tt.file); !cmp.Equal(got, tt.want) {
+				t.Errorf("CodeOwners.Owners(%s) = %v, want %v", tt.file, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIService_GetCodeOwners(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requested = append(requested, req.URL.Path)
+		if req.URL.Path != "/repos/org/repo/contents/CODEOWNERS" {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		rw.Write(readTestResource("get-codeowners/happy-path.json"))
+	}))
+	defer server.Close()
+
+	service := &APIService{BaseURL: server.URL, Client: server.Client()}
+
+	codeOwners := service.GetCodeOwners("org", "repo")
+	if got, want := codeOwners.Owners("file1.txt"), []string{"@octocat", "docs@example.com"}; !cmp.Equal(got, want) {
+		t.Errorf("APIService.GetCodeOwners().Owners() = %v, want %v", got, want)
+	}
+
+	if want := []string{"/repos/org/repo/contents/.github/CODEOWNERS", "/repos/org/repo/contents/CODEOWNERS"}; !cmp.Equal(requested, want) {
+		t.Errorf("APIService.GetCodeOwners() requested %v, want %v", requested, want)
+	}
+
+	if got := service.GetCodeOwners("org", "missing"); got != nil {
+		t.Errorf("APIService.GetCodeOwners() = %v, want nil when the repo has no CODEOWNERS file", got)
+	}
+}
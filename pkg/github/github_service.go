@@ -1,22 +1,36 @@
 package github
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
-	"log"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strings"
+	"time"
 
+	"github.com/aaron-vaz/github-branch-bot/pkg/match"
 	"github.com/aaron-vaz/golang-utils/pkg/errorutil"
 	"github.com/aaron-vaz/golang-utils/pkg/ioutils"
 )
 
 const (
-	getRepositoriesInOrgPath = "/orgs/%s/repos"
-	getBranchesPath          = "/repos/%s/%s/branches"
-	compareBranchesPath      = "/repos/%s/%s/compare/%s...%s"
+	getRepositoriesInOrgPath  = "/orgs/%s/repos"
+	getRepositoriesOfUserPath = "/users/%s/repos"
+	getTeamRepositoriesPath   = "/orgs/%s/teams/%s/repos"
+	getRepositoryPath         = "/repos/%s/%s"
+	getBranchesPath           = "/repos/%s/%s/branches"
+	getBranchPath             = "/repos/%s/%s/branches/%s"
+	compareBranchesPath       = "/repos/%s/%s/compare/%s...%s"
+	getOpenPullRequestsPath   = "/repos/%s/%s/pulls?%s"
+	deleteBranchPath          = "/repos/%s/%s/git/refs/heads/%s"
+	createIssuePath           = "/repos/%s/%s/issues"
+	getOpenIssuesPath         = "/repos/%s/%s/issues?state=open&labels=%s"
+	updateIssuePath           = "/repos/%s/%s/issues/%d"
+	createStatusPath          = "/repos/%s/%s/statuses/%s"
 
 	authorizationHeader = "Authorization"
 	contentTypeHeader   = "Content-Type"
@@ -29,15 +43,138 @@ const (
 
 var linkHeaderRegex = regexp.MustCompile("<([^>]+)>;\\srel=\"next\"+")
 
-// Response is the struct that represents the github branches response
+// Response is the struct that represents the github repository and branches responses
 type Response struct {
-	Name          string `json:"name"`
-	DefaultBranch string `json:"default_branch"`
+	Name          string    `json:"name"`
+	HTMLURL       string    `json:"html_url"`
+	DefaultBranch string    `json:"default_branch"`
+	Archived      bool      `json:"archived"`
+	Disabled      bool      `json:"disabled"`
+	Fork          bool      `json:"fork"`
+	IsTemplate    bool      `json:"is_template"`
+	Topics        []string  `json:"topics"`
+	Visibility    string    `json:"visibility"`
+	PushedAt      time.Time `json:"pushed_at"`
 }
 
 // CompareBranches is the struct that represents the github compare branches response
 type CompareBranches struct {
-	Ahead int `json:"ahead_by"`
+	HTMLURL string        `json:"html_url"`
+	Ahead   int           `json:"ahead_by"`
+	Behind  int           `json:"behind_by"`
+	Commits []Commit      `json:"commits"`
+	Files   []CompareFile `json:"files"`
+}
+
+// CompareFile is the struct that represents a file changed between the compared branches
+type CompareFile struct {
+	Filename string `json:"filename"`
+}
+
+// User is the struct that represents a github user
+type User struct {
+	Login string `json:"login"`
+}
+
+// CommitAuthor is the struct that represents the git author or committer of a commit
+type CommitAuthor struct {
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+	Date  time.Time `json:"date"`
+}
+
+// Commit is the struct that represents a github commit response
+type Commit struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Author    CommitAuthor `json:"author"`
+		Committer CommitAuthor `json:"committer"`
+	} `json:"commit"`
+	Author User `json:"author"`
+}
+
+// AuthorName returns the github login of the commit author, falling back to the git author name
+func (c *Commit) AuthorName() string {
+	if c.Author.Login != "" {
+		return c.Author.Login
+	}
+	return c.Commit.Author.Name
+}
+
+// Branch is the struct that represents the github single branch response
+type Branch struct {
+	Name      string `json:"name"`
+	Protected bool   `json:"protected"`
+	Commit    Commit `json:"commit"`
+}
+
+// LastActivity returns the date of the last commit on the branch
+func (b *Branch) LastActivity() time.Time {
+	return b.Commit.Commit.Committer.Date
+}
+
+// PullRequest is the struct that represents a github pull request response
+type PullRequest struct {
+	Number    int       `json:"number"`
+	HTMLURL   string    `json:"html_url"`
+	CreatedAt time.Time `json:"created_at"`
+	User      User      `json:"user"`
+}
+
+// DaysOpen returns the number of whole days since the pull request was opened
+func (p *PullRequest) DaysOpen() int {
+	return int(time.Since(p.CreatedAt).Hours() / 24)
+}
+
+// Issue is the struct that represents a github issue request and response
+type Issue struct {
+	Number  int    `json:"number,omitempty"`
+	HTMLURL string `json:"html_url,omitempty"`
+	Title   string `json:"title,omitempty"`
+	Body    string `json:"body,omitempty"`
+	State   string `json:"state,omitempty"`
+	Labels  Labels `json:"labels,omitempty"`
+}
+
+// Labels are the names of the labels of an issue, github takes them as names and returns them as label objects
+type Labels []string
+
+// UnmarshalJSON reads labels written as names or as label objects
+func (l *Labels) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	labels := make(Labels, 0, len(raw))
+	for _, item := range raw {
+		label := struct {
+			Name string `json:"name"`
+		}{}
+		if err := json.Unmarshal(item, &label.Name); err != nil {
+			if err := json.Unmarshal(item, &label); err != nil {
+				return err
+			}
+		}
+		labels = append(labels, label.Name)
+	}
+
+	*l = labels
+	return nil
+}
+
+// Commit status states
+const (
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+)
+
+// Status is the struct that represents a github commit status request
+type Status struct {
+	State       string `json:"state"`
+	Description string `json:"description"`
+	Context     string `json:"context"`
+	TargetURL   string `json:"target_url,omitempty"`
 }
 
 // APIService is a service that provides operations allowing you to interact with github api
@@ -45,39 +182,114 @@ type APIService struct {
 	BaseURL string
 	Token   string
 	*http.Client
+	// Tokens supplies the token for each request instead of Token, for example when authenticating as a github app
+	Tokens TokenSource
 }
 
 // GetRepositoriesInOrg returns a list projects that contain the configured base branch as their default branch
-func (s *APIService) GetRepositoriesInOrg(org, baseBranch string) []string {
-	url := s.BaseURL + fmt.Sprintf(getRepositoriesInOrgPath, org)
+// if no base branch is supplied it returns all the projects in the org
+func (s *APIService) GetRepositoriesInOrg(org, baseBranch string) []Response {
+	return s.getRepositories(s.BaseURL+fmt.Sprintf(getRepositoriesInOrgPath, org), baseBranch)
+}
+
+// GetRepositoriesOfUser returns a list of projects owned by the supplied user that contain the configured base branch as their default branch
+// if no base branch is supplied it returns all the projects of the user
+func (s *APIService) GetRepositoriesOfUser(user, baseBranch string) []Response {
+	return s.getRepositories(s.BaseURL+fmt.Sprintf(getRepositoriesOfUserPath, user), baseBranch)
+}
+
+// GetTeamRepositories returns a list of the projects the supplied org team has access to that contain the configured base branch as their default branch
+// if no base branch is supplied it returns all the projects of the team
+func (s *APIService) GetTeamRepositories(org, team, baseBranch string) []Response {
+	return s.getRepositories(s.BaseURL+fmt.Sprintf(getTeamRepositoriesPath, org, team), baseBranch)
+}
+
+// GetRepository returns the supplied repo, the name of the response is empty when the repo could not be found
+func (s *APIService) GetRepository(owner, repo string) *Response {
+	url := s.BaseURL + fmt.Sprintf(getRepositoryPath, owner, repo)
+	response := &Response{}
+
+	body, _ := s.executeGithubRequest(url)
+	errorutil.ErrCheck(json.Unmarshal(body, response), false)
+
+	return response
+}
+
+// ForOwner returns a copy of the service that authenticates requests for the supplied org or user account
+// the supplied token is used when it is set, otherwise github app services use the installation of the app on the owner
+func (s *APIService) ForOwner(owner string, user bool, token string) *APIService {
+	service := *s
+
+	if token != "" {
+		service.Token, service.Tokens = token, nil
+	} else if app, ok := s.Tokens.(*AppTokenSource); ok {
+		service.Tokens = app.ForOwner(owner, user)
+	}
+
+	return &service
+}
+
+// ForRepository returns a copy of the service that authenticates requests for the supplied repo
+// the supplied token is used when it is set, otherwise github app services use the installation of the app on the repo
+// so repos of user accounts can be checked without configuring the user as an owner
+func (s *APIService) ForRepository(owner, repo, token string) *APIService {
+	service := *s
+
+	if token != "" {
+		service.Token, service.Tokens = token, nil
+	} else if app, ok := s.Tokens.(*AppTokenSource); ok {
+		service.Tokens = app.ForRepository(owner, repo)
+	}
+
+	return &service
+}
+
+func (s *APIService) getRepositories(url, baseBranch string) []Response {
 	responses := s.executePaginatedGithubRequest(url)
 
-	var repositories []string
+	var repositories []Response
 	for _, response := range responses {
-		if response.DefaultBranch == baseBranch {
-			repositories = append(repositories, response.Name)
+		if baseBranch == "" || response.DefaultBranch == baseBranch {
+			repositories = append(repositories, response)
 		}
 	}
 
 	return repositories
 }
 
-// GetBranches return all the branches matching the supplied prefix
-// if no prefix is supplied it returns all the branches from the repo
-func (s *APIService) GetBranches(owner, repo string, prefix []string) []string {
-	url := s.BaseURL + fmt.Sprintf(getBranchesPath, owner, repo)
-	responses := s.executePaginatedGithubRequest(url)
+// GetBranches return all the branches matching the supplied matcher
+// if no matcher is supplied it returns all the branches from the repo
+// failures are returned so callers do not mistake a failed lookup for a repo without branches
+func (s *APIService) GetBranches(owner, repo string, matcher *match.Matcher) ([]string, error) {
+	responses, err := s.sendPaginatedGithubRequest(s.BaseURL + fmt.Sprintf(getBranchesPath, owner, repo))
+	if err != nil {
+		return nil, err
+	}
 
 	var branches []string
 	for _, value := range responses {
-		for _, branch := range prefix {
-			if branch == "" || strings.HasPrefix(value.Name, branch) {
-				branches = append(branches, value.Name)
-			}
+		if matcher.Match(value.Name) {
+			branches = append(branches, value.Name)
 		}
 	}
 
-	return branches
+	return branches, nil
+}
+
+// GetBranch returns the supplied branch along with the details of its last commit
+// failures are returned so callers do not act on a branch they could not look up
+func (s *APIService) GetBranch(owner, repo, branch string) (*Branch, error) {
+	body, err := s.sendGithubRequest(http.MethodGet, s.BaseURL+fmt.Sprintf(getBranchPath, owner, repo, escapeBranch(branch)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &Branch{}
+	if err := json.Unmarshal(body, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
 }
 
 func (s *APIService) executePaginatedGithubRequest(url string) []Response {
@@ -98,29 +310,194 @@ func (s *APIService) executePaginatedGithubRequest(url string) []Response {
 	return responses
 }
 
-// GetAheadBy returns how many commits the supplied branch is ahead of the supplied base branch
-func (s *APIService) GetAheadBy(owner, repo, base string, heads []string) map[string]int {
-	results := make(map[string]int)
-	for _, head := range heads {
-		log.Printf("Checking %s branch %s", repo, head)
-		url := s.BaseURL + fmt.Sprintf(compareBranchesPath, owner, repo, base, head)
-		response := &CompareBranches{}
+// GetComparison returns the commits and files the supplied head branch has that the base branch does not
+// failures are returned so callers do not mistake a failed comparison for a branch that is up to date
+func (s *APIService) GetComparison(owner, repo, base, head string) (*CompareBranches, error) {
+	url := s.BaseURL + fmt.Sprintf(compareBranchesPath, owner, repo, escapeBranch(base), escapeBranch(head))
+
+	body, err := s.sendGithubRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &CompareBranches{}
+	if err := json.Unmarshal(body, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// GetOpenPullRequests returns the open pull requests that merge the supplied head branch into the supplied base branch
+// if no base branch is supplied it returns the open pull requests from the head branch into any branch
+// if no head branch is supplied it returns the open pull requests from any branch into the base branch
+// failures are returned so callers do not mistake a failed lookup for a branch without pull requests
+func (s *APIService) GetOpenPullRequests(owner, repo, base, head string) ([]PullRequest, error) {
+	query := url.Values{"state": {"open"}}
+	if head != "" {
+		query.Set("head", owner+":"+head)
+	}
+	if base != "" {
+		query.Set("base", base)
+	}
+
+	body, err := s.sendGithubRequest(http.MethodGet, s.BaseURL+fmt.Sprintf(getOpenPullRequestsPath, owner, repo, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	pulls := []PullRequest{}
+	if err := json.Unmarshal(body, &pulls); err != nil {
+		return nil, err
+	}
+
+	return pulls, nil
+}
+
+// DeleteBranch deletes the supplied branch ref from the repo
+func (s *APIService) DeleteBranch(owner, repo, branch string) error {
+	url := s.BaseURL + fmt.Sprintf(deleteBranchPath, owner, repo, escapeBranch(branch))
+	_, err := s.sendGithubRequest(http.MethodDelete, url, nil)
+	return err
+}
+
+// CreateIssue opens the supplied issue in the repo and returns the created issue
+func (s *APIService) CreateIssue(owner, repo string, issue Issue) (*Issue, error) {
+	body, err := s.sendGithubRequest(http.MethodPost, s.BaseURL+fmt.Sprintf(createIssuePath, owner, repo), issue)
+	if err != nil {
+		return nil, err
+	}
+
+	created := &Issue{}
+	if err := json.Unmarshal(body, created); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// GetOpenIssues returns the open issues of the repo with the supplied label
+// failures are returned so callers do not open duplicate issues
+func (s *APIService) GetOpenIssues(owner, repo, label string) ([]Issue, error) {
+	body, err := s.sendGithubRequest(http.MethodGet, s.BaseURL+fmt.Sprintf(getOpenIssuesPath, owner, repo, url.QueryEscape(label)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := []Issue{}
+	if err := json.Unmarshal(body, &issues); err != nil {
+		return nil, err
+	}
+
+	return issues, nil
+}
+
+// UpdateIssue edits the supplied issue of the repo, fields that are not set are left unchanged
+func (s *APIService) UpdateIssue(owner, repo string, number int, issue Issue) (*Issue, error) {
+	body, err := s.sendGithubRequest(http.MethodPatch, s.BaseURL+fmt.Sprintf(updateIssuePath, owner, repo, number), issue)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := &Issue{}
+	if err := json.Unmarshal(body, updated); err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// CreateStatus sets the supplied commit status on the commit, a status with the same context replaces the previous one
+func (s *APIService) CreateStatus(owner, repo, sha string, status Status) error {
+	_, err := s.sendGithubRequest(http.MethodPost, s.BaseURL+fmt.Sprintf(createStatusPath, owner, repo, sha), status)
+	return err
+}
+
+// sendPaginatedGithubRequest requests every page of the supplied list, a page that fails is returned as an error rather than ending the list
+func (s *APIService) sendPaginatedGithubRequest(url string) ([]Response, error) {
+	var responses []Response
+	for url != "" {
+		body, header, err := s.doGithubRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		page := []Response{}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, err
+		}
+
+		responses = append(responses, page...)
+		url = s.getNextLink(header.Get(linkHeader))
+	}
+
+	return responses, nil
+}
+
+// sendGithubRequest performs a request against the github api, non 2xx responses are returned as errors
+// the payload is sent as the json body of the request when it is not nil
+func (s *APIService) sendGithubRequest(method, url string, payload interface{}) ([]byte, error) {
+	body, _, err := s.doGithubRequest(method, url, payload)
+	return body, err
+}
+
+// doGithubRequest performs the request for sendGithubRequest, the response headers are returned for paginated lists
+func (s *APIService) doGithubRequest(method, url string, payload interface{}) ([]byte, http.Header, error) {
+	var reader io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.addHeaders(req); err != nil {
+		return nil, nil, err
+	}
+
+	res, err := s.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer ioutils.Close(res.Body)
 
-		body, _ := s.executeGithubRequest(url)
-		errorutil.ErrCheck(json.Unmarshal(body, response), false)
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		results[head] = response.Ahead
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return body, nil, fmt.Errorf("github %s %s failed with status %s", method, url, res.Status)
 	}
 
-	return results
+	return body, res.Header, nil
+}
+
+func (s *APIService) addHeaders(req *http.Request) error {
+	token := s.Token
+	if s.Tokens != nil {
+		var err error
+		if token, err = s.Tokens.Token(); err != nil {
+			return err
+		}
+	}
+
+	req.Header.Add(authorizationHeader, tokenHeaderPrefix+token)
+	req.Header.Add(contentTypeHeader, jsonMediaType)
+	return nil
 }
 
 func (s *APIService) executeGithubRequest(url string) ([]byte, string) {
 	req, err := http.NewRequest(http.MethodGet, url, nil)
 	errorutil.ErrCheck(err, false)
-
-	req.Header.Add(authorizationHeader, tokenHeaderPrefix+s.Token)
-	req.Header.Add(contentTypeHeader, jsonMediaType)
+	errorutil.ErrCheck(s.addHeaders(req), false)
 
 	res, err := s.Do(req)
 	errorutil.ErrCheck(err, false)
@@ -135,6 +512,17 @@ func (s *APIService) executeGithubRequest(url string) ([]byte, string) {
 	return body, s.getNextLink(res.Header.Get(linkHeader))
 }
 
+// escapeBranch escapes the supplied branch name for use in a url path, the slashes separating its segments are kept
+// so characters such as # and + are sent as part of the name rather than starting a fragment or becoming a space
+func escapeBranch(branch string) string {
+	segments := strings.Split(branch, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
+
 func (s *APIService) getNextLink(header string) string {
 	if matches := linkHeaderRegex.FindStringSubmatch(header); len(matches) > 1 {
 		if value := strings.TrimSpace(matches[1]); value != "" {
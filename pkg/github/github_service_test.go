@@ -1,14 +1,19 @@
 package github
 
 import (
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/aaron-vaz/github-branch-bot/pkg/match"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -24,6 +29,11 @@ var (
 
 	noResponseServer = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 	}))
+
+	rateLimitedServer = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusForbidden)
+		rw.Write([]byte(`{"message": "API rate limit exceeded"}`))
+	}))
 )
 
 func TestAPIService_GetBranches(t *testing.T) {
@@ -48,54 +58,79 @@ func TestAPIService_GetBranches(t *testing.T) {
 		service *APIService
 		args    args
 		want    []string
+		wantErr bool
 	}{
 		{
 			name:    "Test Happy Path with 1 prefix",
 			server:  jsonServer,
-			service: &APIService{jsonServer.URL, githubToken, jsonServer.Client()},
+			service: &APIService{BaseURL: jsonServer.URL, Token: githubToken, Client: jsonServer.Client()},
 			args:    args{prefix: []string{"master"}},
 			want:    []string{"master"},
 		},
 		{
 			name:    "Test Happy Path with 2 prefix",
 			server:  jsonServer,
-			service: &APIService{jsonServer.URL, githubToken, jsonServer.Client()},
+			service: &APIService{BaseURL: jsonServer.URL, Token: githubToken, Client: jsonServer.Client()},
 			args:    args{prefix: []string{"master", "develop"}},
 			want:    []string{"develop", "master"},
 		},
 		{
 			name:    "Test Happy Path without prefix",
 			server:  jsonServer,
-			service: &APIService{jsonServer.URL, githubToken, jsonServer.Client()},
+			service: &APIService{BaseURL: jsonServer.URL, Token: githubToken, Client: jsonServer.Client()},
 			args:    args{prefix: []string{""}},
 			want:    []string{"develop", "master", "release"},
 		},
+		{
+			name:    "Test Happy Path with glob and exclude",
+			server:  jsonServer,
+			service: &APIService{BaseURL: jsonServer.URL, Token: githubToken, Client: jsonServer.Client()},
+			args:    args{prefix: []string{"*", "!develop"}},
+			want:    []string{"master", "release"},
+		},
 		{
 			name:    "Test Prefix doesn't match",
 			server:  jsonServer,
-			service: &APIService{jsonServer.URL, githubToken, jsonServer.Client()},
+			service: &APIService{BaseURL: jsonServer.URL, Token: githubToken, Client: jsonServer.Client()},
 			args:    args{prefix: []string{"test"}},
 			want:    []string{},
 		},
 		{
 			name:    "Test no response path",
 			server:  noResponseServer,
-			service: &APIService{noResponseServer.URL, githubToken, noResponseServer.Client()},
+			service: &APIService{BaseURL: noResponseServer.URL, Token: githubToken, Client: noResponseServer.Client()},
 			args:    args{prefix: []string{"master"}},
-			want:    []string{},
+			wantErr: true,
 		},
 		{
 			name:    "Test invalid JSON path",
 			server:  invalidJSONServer,
-			service: &APIService{invalidJSONServer.URL, githubToken, invalidJSONServer.Client()},
+			service: &APIService{BaseURL: invalidJSONServer.URL, Token: githubToken, Client: invalidJSONServer.Client()},
 			args:    args{prefix: []string{"master"}},
-			want:    []string{},
+			wantErr: true,
+		},
+		{
+			name:    "Test rate limited path",
+			server:  rateLimitedServer,
+			service: &APIService{BaseURL: rateLimitedServer.URL, Token: githubToken, Client: rateLimitedServer.Client()},
+			args:    args{prefix: []string{"master"}},
+			wantErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := tt.service.GetBranches("test", "test", tt.args.prefix); !reflect.DeepEqual(got, tt.want) {
+			matcher, err := match.Compile(tt.args.prefix, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := tt.service.GetBranches("test", "test", matcher)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("APIService.GetBranches() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
 				if len(got) == 0 && len(tt.want) == 0 {
 					return
 				}
@@ -106,40 +141,406 @@ func TestAPIService_GetBranches(t *testing.T) {
 	}
 }
 
-func TestAPIService_GetAheadBy(t *testing.T) {
+func TestAPIService_GetBranches_Pages(t *testing.T) {
+	var server *httptest.Server
+	failPage := false
+	server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("page") == "" {
+			rw.Header().Set("Link", fmt.Sprintf(`<%s%s?page=2>; rel="next"`, server.URL, req.URL.Path))
+			rw.Write([]byte(`[{"name": "master"}]`))
+			return
+		}
+
+		if failPage {
+			rw.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		rw.Write([]byte(`[{"name": "release"}]`))
+	}))
+	defer server.Close()
+
+	service := &APIService{BaseURL: server.URL, Token: githubToken, Client: server.Client()}
+
+	if got, err := service.GetBranches("test", "test", nil); err != nil || !reflect.DeepEqual(got, []string{"master", "release"}) {
+		t.Errorf("APIService.GetBranches() = %v, %v, want the branches of every page", got, err)
+	}
+
+	// a failed page must not pass for the end of the list
+	failPage = true
+	if got, err := service.GetBranches("test", "test", nil); err == nil {
+		t.Errorf("APIService.GetBranches() = %v, want an error when a page fails", got)
+	}
+}
+
+func TestAPIService_GetBranch(t *testing.T) {
+	jsonServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/repos/test/test/branches/master" {
+			t.Errorf("Unexpected request path, got = %s", req.URL.Path)
+		}
+
+		rw.Write(readTestResource("get-branch/happy-path.json"))
+	}))
+
+	type want struct {
+		sha          string
+		author       string
+		protected    bool
+		lastActivity time.Time
+	}
+
+	tests := []struct {
+		name    string
+		service *APIService
+		want    want
+		wantErr bool
+	}{
+		{
+			name:    "Test Happy Path",
+			service: &APIService{BaseURL: jsonServer.URL, Token: githubToken, Client: jsonServer.Client()},
+			want: want{
+				sha:          "7fd1a60b01f91b314f59955a4e4d4e80d8edf11d",
+				author:       "octocat",
+				protected:    true,
+				lastActivity: time.Date(2012, 3, 6, 23, 6, 50, 0, time.UTC),
+			},
+		},
+		{
+			name:    "Test invalid JSON path",
+			service: &APIService{BaseURL: invalidJSONServer.URL, Token: githubToken, Client: invalidJSONServer.Client()},
+			wantErr: true,
+		},
+		{
+			name:    "Test no response path",
+			service: &APIService{BaseURL: noResponseServer.URL, Token: githubToken, Client: noResponseServer.Client()},
+			wantErr: true,
+		},
+		{
+			name:    "Test rate limited path",
+			service: &APIService{BaseURL: rateLimitedServer.URL, Token: githubToken, Client: rateLimitedServer.Client()},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			branch, err := tt.service.GetBranch("test", "test", "master")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("APIService.GetBranch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			got := want{
+				sha:          branch.Commit.SHA,
+				author:       branch.Commit.AuthorName(),
+				protected:    branch.Protected,
+				lastActivity: branch.LastActivity(),
+			}
+
+			if got != tt.want {
+				t.Errorf("APIService.GetBranch() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIService_EscapesBranchNames(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests = append(requests, req.Method+" "+req.URL.EscapedPath()+" "+req.URL.RawQuery)
+
+		if req.Method == http.MethodDelete {
+			rw.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if strings.Contains(req.URL.Path, "pulls") {
+			rw.Write([]byte("[]"))
+			return
+		}
+
+		rw.Write([]byte(`{"name": "fix#12"}`))
+	}))
+	defer server.Close()
+
+	service := &APIService{BaseURL: server.URL, Token: githubToken, Client: server.Client()}
+
+	if branch, err := service.GetBranch("test", "test", "fix#12"); err != nil || branch.Name != "fix#12" {
+		t.Errorf("APIService.GetBranch() = %+v, %v", branch, err)
+	}
+	if err := service.DeleteBranch("test", "test", "feature/fix#12"); err != nil {
+		t.Errorf("APIService.DeleteBranch() error = %v", err)
+	}
+	if _, err := service.GetOpenPullRequests("test", "test", "c++", "feature/c++"); err != nil {
+		t.Errorf("APIService.GetOpenPullRequests() error = %v", err)
+	}
+	if _, err := service.GetOpenPullRequests("test", "test", "c++", ""); err != nil {
+		t.Errorf("APIService.GetOpenPullRequests() error = %v", err)
+	}
+	service.GetComparison("test", "test", "develop", "feature/fix#12")
+
+	want := []string{
+		"GET /repos/test/test/branches/fix%2312 ",
+		"DELETE /repos/test/test/git/refs/heads/feature/fix%2312 ",
+		"GET /repos/test/test/pulls base=c%2B%2B&head=test%3Afeature%2Fc%2B%2B&state=open",
+		"GET /repos/test/test/pulls base=c%2B%2B&state=open",
+		"GET /repos/test/test/compare/develop...feature/fix%2312 ",
+	}
+	if !cmp.Equal(requests, want) {
+		t.Errorf("Unexpected github requests %s", cmp.Diff(want, requests))
+	}
+}
+
+func TestAPIService_GetComparison(t *testing.T) {
 	jsonServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
-		rw.Write(readTestResource("get-ahead-by/happy-path.json"))
+		rw.Write(readTestResource("get-comparison/happy-path.json"))
 	}))
 
 	tests := []struct {
 		name    string
 		server  *httptest.Server
 		service *APIService
-		want    map[string]int
+		want    int
+		wantErr bool
 	}{
 		{
 			name:    "Test Happy Path",
 			server:  jsonServer,
-			service: &APIService{jsonServer.URL, githubToken, jsonServer.Client()},
-			want:    map[string]int{"master": 1},
+			service: &APIService{BaseURL: jsonServer.URL, Token: githubToken, Client: jsonServer.Client()},
+			want:    1,
 		},
 		{
-			name:    "Test invalid JSOn path",
+			name:    "Test invalid JSON path",
 			server:  invalidJSONServer,
-			service: &APIService{invalidJSONServer.URL, githubToken, invalidJSONServer.Client()},
-			want:    map[string]int{"master": 0},
+			service: &APIService{BaseURL: invalidJSONServer.URL, Token: githubToken, Client: invalidJSONServer.Client()},
+			wantErr: true,
 		},
 		{
 			name:    "Test no response path",
 			server:  noResponseServer,
-			service: &APIService{noResponseServer.URL, githubToken, noResponseServer.Client()},
-			want:    map[string]int{"master": 0},
+			service: &APIService{BaseURL: noResponseServer.URL, Token: githubToken, Client: noResponseServer.Client()},
+			wantErr: true,
+		},
+		{
+			name:    "Test rate limited path",
+			server:  rateLimitedServer,
+			service: &APIService{BaseURL: rateLimitedServer.URL, Token: githubToken, Client: rateLimitedServer.Client()},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.service.GetComparison("test", "test", "develop", "master")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("APIService.GetComparison() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil && got.Ahead != tt.want {
+				t.Errorf("APIService.GetComparison().Ahead = %v, want %v", got.Ahead, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIService_GetOpenPullRequests(t *testing.T) {
+	jsonServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if head := req.URL.Query().Get("head"); head != "test:master" {
+			t.Errorf("Unexpected head filter, want = test:master, got = %s", head)
+		}
+
+		if base := req.URL.Query().Get("base"); base != "develop" {
+			t.Errorf("Unexpected base filter, want = develop, got = %s", base)
+		}
+
+		rw.Write(readTestResource("get-open-pull-requests/happy-path.json"))
+	}))
+
+	tests := []struct {
+		name    string
+		service *APIService
+		want    []int
+		wantErr bool
+	}{
+		{
+			name:    "Test Happy Path",
+			service: &APIService{BaseURL: jsonServer.URL, Token: githubToken, Client: jsonServer.Client()},
+			want:    []int{1347},
+		},
+		{
+			name:    "Test invalid JSON path",
+			service: &APIService{BaseURL: invalidJSONServer.URL, Token: githubToken, Client: invalidJSONServer.Client()},
+			wantErr: true,
+		},
+		{
+			name:    "Test no response path",
+			service: &APIService{BaseURL: noResponseServer.URL, Token: githubToken, Client: noResponseServer.Client()},
+			wantErr: true,
+		},
+		{
+			name:    "Test rate limited path",
+			service: &APIService{BaseURL: rateLimitedServer.URL, Token: githubToken, Client: rateLimitedServer.Client()},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pulls, err := tt.service.GetOpenPullRequests("test", "test", "develop", "master")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("APIService.GetOpenPullRequests() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			got := []int{}
+			for _, pull := range pulls {
+				got = append(got, pull.Number)
+
+				if pull.User.Login != "octocat" {
+					t.Errorf("Unexpected pull request author, want = octocat, got = %s", pull.User.Login)
+				}
+			}
+
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("APIService.GetOpenPullRequests() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIService_DeleteBranch(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{
+			name:    "Test Happy Path",
+			status:  http.StatusNoContent,
+			wantErr: false,
+		},
+		{
+			name:    "Test ref doesn't exist path",
+			status:  http.StatusUnprocessableEntity,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				if req.Method != http.MethodDelete {
+					t.Errorf("Request was not made via DELETE, got = %s", req.Method)
+				}
+
+				if req.URL.Path != "/repos/test/test/git/refs/heads/release/1.0" {
+					t.Errorf("Unexpected request path, got = %s", req.URL.Path)
+				}
+
+				rw.WriteHeader(tt.status)
+			}))
+
+			service := &APIService{BaseURL: server.URL, Token: githubToken, Client: server.Client()}
+			if err := service.DeleteBranch("test", "test", "release/1.0"); (err != nil) != tt.wantErr {
+				t.Errorf("APIService.DeleteBranch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAPIService_CreateIssue(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		want    *Issue
+		wantErr bool
+	}{
+		{
+			name:   "Test Happy Path",
+			status: http.StatusCreated,
+			want:   &Issue{Number: 1, HTMLURL: "https://github.com/test/test/issues/1", Title: "Branch drift", Labels: Labels{"branch-drift"}},
+		},
+		{
+			name:    "Test issues disabled path",
+			status:  http.StatusGone,
+			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := tt.service.GetAheadBy("test", "test", "develop", []string{"master"}); !cmp.Equal(got, tt.want) {
-				t.Errorf("APIService.GetAheadBy() = %v, want %v", got, tt.want)
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				if req.Method != http.MethodPost {
+					t.Errorf("Request was not made via POST, got = %s", req.Method)
+				}
+
+				if req.URL.Path != "/repos/test/test/issues" {
+					t.Errorf("Unexpected request path, got = %s", req.URL.Path)
+				}
+
+				issue := Issue{}
+				if err := json.NewDecoder(req.Body).Decode(&issue); err != nil {
+					t.Errorf("Could not decode the issue: %v", err)
+				}
+
+				issue.Number, issue.HTMLURL = 1, "https://github.com/test/test/issues/1"
+				rw.WriteHeader(tt.status)
+				json.NewEncoder(rw).Encode(issue)
+			}))
+
+			service := &APIService{BaseURL: server.URL, Token: githubToken, Client: server.Client()}
+			got, err := service.CreateIssue("test", "test", Issue{Title: "Branch drift", Labels: []string{"branch-drift"}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("APIService.CreateIssue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("APIService.CreateIssue() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIService_CreateStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{
+			name:   "Test Happy Path",
+			status: http.StatusCreated,
+		},
+		{
+			name:    "Test commit doesn't exist path",
+			status:  http.StatusUnprocessableEntity,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := Status{State: StatusFailure, Description: "master is 3 commits ahead", Context: "branch-bot"}
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				if req.Method != http.MethodPost {
+					t.Errorf("Request was not made via POST, got = %s", req.Method)
+				}
+
+				if req.URL.Path != "/repos/test/test/statuses/6dcb09b" {
+					t.Errorf("Unexpected request path, got = %s", req.URL.Path)
+				}
+
+				got := Status{}
+				if err := json.NewDecoder(req.Body).Decode(&got); err != nil || got != want {
+					t.Errorf("Unexpected status %+v, want %+v", got, want)
+				}
+
+				rw.WriteHeader(tt.status)
+			}))
+
+			service := &APIService{BaseURL: server.URL, Token: githubToken, Client: server.Client()}
+			if err := service.CreateStatus("test", "test", "6dcb09b", want); (err != nil) != tt.wantErr {
+				t.Errorf("APIService.CreateStatus() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
@@ -169,6 +570,14 @@ func TestAPIService_GetRepositoriesInOrg(t *testing.T) {
 			want:       []string{},
 		},
 
+		{
+			name:       "Test no base branch path",
+			org:        "test",
+			baseBranch: "",
+			response:   readTestResource("get-repos-in-org/happy-path.json"),
+			want:       []string{"test"},
+		},
+
 		{
 			name:       "Test invalid json path",
 			org:        "test",
@@ -189,7 +598,12 @@ func TestAPIService_GetRepositoriesInOrg(t *testing.T) {
 				Client:  server.Client(),
 			}
 
-			if got := service.GetRepositoriesInOrg(tt.org, tt.baseBranch); !cmp.Equal(got, tt.want) {
+			var got []string
+			for _, repo := range service.GetRepositoriesInOrg(tt.org, tt.baseBranch) {
+				got = append(got, repo.Name)
+			}
+
+			if !cmp.Equal(got, tt.want) {
 				if len(got) == 0 && len(tt.want) == 0 {
 					return
 				}
@@ -200,6 +614,206 @@ func TestAPIService_GetRepositoriesInOrg(t *testing.T) {
 	}
 }
 
+func TestAPIService_GetRepositoriesOfUser(t *testing.T) {
+	var requestedPath string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requestedPath = req.URL.Path
+		rw.Write(readTestResource("get-repos-in-org/happy-path.json"))
+	}))
+	defer server.Close()
+
+	service := &APIService{BaseURL: server.URL, Client: server.Client()}
+
+	var got []string
+	for _, repo := range service.GetRepositoriesOfUser("someone", "master") {
+		got = append(got, repo.Name)
+	}
+
+	if want := []string{"test"}; !cmp.Equal(got, want) {
+		t.Errorf("APIService.GetRepositoriesOfUser() = %v, want %v", got, want)
+	}
+
+	if want := "/users/someone/repos"; requestedPath != want {
+		t.Errorf("APIService.GetRepositoriesOfUser() requested %s, want %s", requestedPath, want)
+	}
+
+	repo := service.GetRepositoriesOfUser("someone", "")[0]
+	if !repo.Fork || repo.Archived || !cmp.Equal(repo.Topics, []string{"octocat", "atom", "electron", "API"}) || repo.PushedAt.IsZero() {
+		t.Errorf("APIService.GetRepositoriesOfUser() did not decode the repo details, got %+v", repo)
+	}
+}
+
+func TestAPIService_GetTeamRepositories(t *testing.T) {
+	var requestedPath string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requestedPath = req.URL.Path
+		rw.Write(readTestResource("get-repos-in-org/happy-path.json"))
+	}))
+	defer server.Close()
+
+	service := &APIService{BaseURL: server.URL, Client: server.Client()}
+
+	var got []string
+	for _, repo := range service.GetTeamRepositories("org", "payments", "master") {
+		got = append(got, repo.Name)
+	}
+
+	if want := []string{"test"}; !cmp.Equal(got, want) {
+		t.Errorf("APIService.GetTeamRepositories() = %v, want %v", got, want)
+	}
+
+	if want := "/orgs/org/teams/payments/repos"; requestedPath != want {
+		t.Errorf("APIService.GetTeamRepositories() requested %s, want %s", requestedPath, want)
+	}
+}
+
+func TestAPIService_GetRepository(t *testing.T) {
+	tests := []struct {
+		name     string
+		response []byte
+		want     string
+	}{
+		{
+			name:     "Test Happy Path",
+			response: readTestResource("get-repository/happy-path.json"),
+			want:     "test",
+		},
+		{
+			name:     "Test invalid json path",
+			response: readTestResource("invalid.json"),
+			want:     "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				if req.URL.Path != "/repos/octocat/test" {
+					t.Errorf("APIService.GetRepository() requested %s", req.URL.Path)
+				}
+				rw.Write(tt.response)
+			}))
+			defer server.Close()
+
+			service := &APIService{BaseURL: server.URL, Client: server.Client()}
+			if got := service.GetRepository("octocat", "test"); got.Name != tt.want {
+				t.Errorf("APIService.GetRepository() = %v, want %v", got.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIService_ForOwner(t *testing.T) {
+	app := &AppTokenSource{Org: "org"}
+
+	tests := []struct {
+		name       string
+		service    *APIService
+		owner      string
+		user       bool
+		token      string
+		wantToken  string
+		wantTokens TokenSource
+	}{
+		{
+			name:      "Test personal access token path",
+			service:   &APIService{Token: githubToken},
+			owner:     "other-org",
+			wantToken: githubToken,
+		},
+		{
+			name:      "Test owner token path",
+			service:   &APIService{Token: githubToken, Tokens: app},
+			owner:     "other-org",
+			token:     "owner-token",
+			wantToken: "owner-token",
+		},
+		{
+			name:       "Test app installation path",
+			service:    &APIService{Tokens: app},
+			owner:      "org",
+			wantTokens: app,
+		},
+		{
+			name:       "Test app installation on other owner path",
+			service:    &APIService{Tokens: app},
+			owner:      "someone",
+			user:       true,
+			wantTokens: app.ForOwner("someone", true),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.service.ForOwner(tt.owner, tt.user, tt.token)
+			if got == tt.service {
+				t.Errorf("APIService.ForOwner() returned the same service")
+			}
+
+			if got.Token != tt.wantToken || got.Tokens != tt.wantTokens {
+				t.Errorf("APIService.ForOwner() = %+v, want token %s and token source %v", got, tt.wantToken, tt.wantTokens)
+			}
+		})
+	}
+
+	if source := app.ForOwner("someone", true); source.Org != "someone" || !source.User {
+		t.Errorf("AppTokenSource.ForOwner() = %+v, want a source for the someone user", source)
+	}
+}
+
+func TestAPIService_ForRepository(t *testing.T) {
+	app := &AppTokenSource{Org: "org"}
+
+	tests := []struct {
+		name       string
+		service    *APIService
+		owner      string
+		token      string
+		wantToken  string
+		wantTokens TokenSource
+	}{
+		{
+			name:      "Test personal access token path",
+			service:   &APIService{Token: githubToken},
+			owner:     "someone",
+			wantToken: githubToken,
+		},
+		{
+			name:      "Test owner token path",
+			service:   &APIService{Token: githubToken, Tokens: app},
+			owner:     "someone",
+			token:     "owner-token",
+			wantToken: "owner-token",
+		},
+		{
+			name:       "Test app installation path",
+			service:    &APIService{Tokens: app},
+			owner:      "org",
+			wantTokens: app,
+		},
+		{
+			name:       "Test app installation on other owner path",
+			service:    &APIService{Tokens: app},
+			owner:      "someone",
+			wantTokens: app.ForRepository("someone", "repo"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.service.ForRepository(tt.owner, "repo", tt.token)
+			if got == tt.service {
+				t.Errorf("APIService.ForRepository() returned the same service")
+			}
+
+			if got.Token != tt.wantToken || got.Tokens != tt.wantTokens {
+				t.Errorf("APIService.ForRepository() = %+v, want token %s and token source %v", got, tt.wantToken, tt.wantTokens)
+			}
+		})
+	}
+
+	if source := app.ForRepository("someone", "repo"); source.Org != "someone" || source.Repo != "repo" || source.User {
+		t.Errorf("AppTokenSource.ForRepository() = %+v, want a source for the someone/repo repo", source)
+	}
+}
+
 func readTestResource(path string) []byte {
 	content, err := ioutil.ReadFile(filepath.Join(testResources, path))
 	if err != nil {
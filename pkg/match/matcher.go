@@ -0,0 +1,198 @@
+package match
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	excludePrefix = "!"
+	regexPrefix   = "re:"
+	globChars     = "*?"
+)
+
+// Pattern is a single compiled branch pattern
+//
+// Patterns support the following syntax:
+//   - plain text such as release matches every branch starting with that text, an empty pattern matches everything
+//   - globs such as release/* where * matches within a path segment, ** matches across segments and ? matches a single character
+//   - regular expressions prefixed with re: such as re:^release/\d+\.\d+$
+type Pattern struct {
+	Raw string
+	re  *regexp.Regexp
+}
+
+// ParsePattern compiles the supplied pattern, returning an error if it is not valid
+func ParsePattern(raw string) (*Pattern, error) {
+	var expr string
+
+	switch {
+	case strings.HasPrefix(raw, regexPrefix):
+		expr = strings.TrimPrefix(raw, regexPrefix)
+		if expr == "" {
+			return nil, fmt.Errorf("pattern %q: empty regular expression", raw)
+		}
+
+	case strings.ContainsAny(raw, globChars):
+		expr = globToRegex(raw)
+
+	default:
+		expr = "^" + regexp.QuoteMeta(raw)
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("pattern %q: %v", raw, err)
+	}
+
+	return &Pattern{Raw: raw, re: re}, nil
+}
+
+// MatchString reports whether the supplied branch name matches the pattern
+func (p *Pattern) MatchString(name string) bool {
+	return p.re.MatchString(name)
+}
+
+func (p *Pattern) String() string {
+	if p == nil {
+		return ""
+	}
+	return p.Raw
+}
+
+// Matcher selects branch names using a list of include and exclude patterns
+type Matcher struct {
+	Include []*Pattern
+	Exclude []*Pattern
+}
+
+// Compile builds a Matcher from the supplied include and exclude patterns
+// include patterns starting with ! are treated as exclude patterns and empty exclude patterns are ignored
+func Compile(include, exclude []string) (*Matcher, error) {
+	m := &Matcher{}
+
+	var errs []string
+	add := func(raw string, negate bool) {
+		if strings.HasPrefix(raw, excludePrefix) {
+			raw = strings.TrimPrefix(raw, excludePrefix)
+			negate = true
+		}
+
+		raw = strings.TrimSpace(raw)
+		if negate && raw == "" {
+			return
+		}
+
+		pattern, err := ParsePattern(raw)
+		if err != nil {
+			errs = append(errs, err.Error())
+			return
+		}
+
+		if negate {
+			m.Exclude = append(m.Exclude, pattern)
+		} else {
+			m.Include = append(m.Include, pattern)
+		}
+	}
+
+	for _, raw := range include {
+		add(raw, false)
+	}
+
+	for _, raw := range exclude {
+		add(raw, true)
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid branch patterns: %s", strings.Join(errs, "; "))
+	}
+
+	return m, nil
+}
+
+// Selection is a branch selected by a Matcher along with the include pattern that selected it
+type Selection struct {
+	Name    string
+	Pattern *Pattern
+}
+
+// Match reports whether the supplied branch name matches at least one include pattern and no exclude patterns
+// if there are no include patterns every branch that is not excluded matches
+func (m *Matcher) Match(name string) bool {
+	_, ok := m.find(name)
+	return ok
+}
+
+// Select returns the supplied branch names that match, each name appears once and in the order it was supplied
+// when several include patterns match a name the first one in the include list takes precedence
+func (m *Matcher) Select(names []string) []Selection {
+	var selections []Selection
+	seen := make(map[string]bool)
+
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+
+		if pattern, ok := m.find(name); ok {
+			seen[name] = true
+			selections = append(selections, Selection{Name: name, Pattern: pattern})
+		}
+	}
+
+	return selections
+}
+
+// find returns the first include pattern matching the supplied name
+// the pattern is nil when the name matches because there are no include patterns
+func (m *Matcher) find(name string) (*Pattern, bool) {
+	if m == nil {
+		return nil, true
+	}
+
+	for _, pattern := range m.Exclude {
+		if pattern.MatchString(name) {
+			return nil, false
+		}
+	}
+
+	if len(m.Include) == 0 {
+		return nil, true
+	}
+
+	for _, pattern := range m.Include {
+		if pattern.MatchString(name) {
+			return pattern, true
+		}
+	}
+
+	return nil, false
+}
+
+func globToRegex(glob string) string {
+	var expr strings.Builder
+	expr.WriteString("^")
+
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				expr.WriteString(".*")
+				i++
+			} else {
+				expr.WriteString("[^/]*")
+			}
+
+		case '?':
+			expr.WriteString("[^/]")
+
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	expr.WriteString("$")
+	return expr.String()
+}
@@ -0,0 +1,219 @@
+package match
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		branch  string
+		want    bool
+		wantErr bool
+	}{
+		{name: "Test prefix match path", pattern: "release", branch: "release/1.0", want: true},
+		{name: "Test prefix exact match path", pattern: "master", branch: "master", want: true},
+		{name: "Test prefix no match path", pattern: "release", branch: "hotfix/release", want: false},
+		{name: "Test empty pattern path", pattern: "", branch: "anything/at/all", want: true},
+		{name: "Test prefix with regex characters path", pattern: "v1.0", branch: "v1x0", want: false},
+		{name: "Test single star path", pattern: "release/*", branch: "release/1.0", want: true},
+		{name: "Test single star doesn't cross segments path", pattern: "release/*", branch: "release/1.0/rc1", want: false},
+		{name: "Test single star requires separator path", pattern: "release/*", branch: "release", want: false},
+		{name: "Test double star path", pattern: "hotfix/**", branch: "hotfix/1.0/urgent", want: true},
+		{name: "Test double star no match path", pattern: "hotfix/**", branch: "feature/hotfix/1.0", want: false},
+		{name: "Test star in the middle path", pattern: "release-*-rc", branch: "release-1.0-rc", want: true},
+		{name: "Test question mark path", pattern: "v?", branch: "v2", want: true},
+		{name: "Test question mark doesn't match separator path", pattern: "v?", branch: "v/", want: false},
+		{name: "Test glob escapes regex characters path", pattern: "release/1.*", branch: "release/1x0", want: false},
+		{name: "Test regex path", pattern: `re:^release/\d+\.\d+$`, branch: "release/2.13", want: true},
+		{name: "Test regex no match path", pattern: `re:^release/\d+\.\d+$`, branch: "release/2.13.1", want: false},
+		{name: "Test unanchored regex path", pattern: "re:fix", branch: "feature/hotfix-1", want: true},
+		{name: "Test invalid regex path", pattern: "re:release/(", wantErr: true},
+		{name: "Test empty regex path", pattern: "re:", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern, err := ParsePattern(tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePattern() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if got := pattern.MatchString(tt.branch); got != tt.want {
+				t.Errorf("Pattern(%q).MatchString(%q) = %v, want %v", tt.pattern, tt.branch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatcher_Match(t *testing.T) {
+	branches := []string{"master", "develop", "release/1.0", "release/legacy-2", "hotfix/1.0/urgent", "feature/login"}
+
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "Test prefixes path",
+			include: []string{"master", "release"},
+			want:    []string{"master", "release/1.0", "release/legacy-2"},
+		},
+		{
+			name:    "Test inline exclude path",
+			include: []string{"release/*", "!release/legacy-*"},
+			want:    []string{"release/1.0"},
+		},
+		{
+			name:    "Test exclude list path",
+			include: []string{"release/*", "hotfix/**"},
+			exclude: []string{"release/legacy-*"},
+			want:    []string{"release/1.0", "hotfix/1.0/urgent"},
+		},
+		{
+			name:    "Test exclude list with negation path",
+			include: []string{"release"},
+			exclude: []string{"!re:legacy"},
+			want:    []string{"release/1.0"},
+		},
+		{
+			name:    "Test only excludes path",
+			exclude: []string{"feature/**", "release"},
+			want:    []string{"master", "develop", "hotfix/1.0/urgent"},
+		},
+		{
+			name:    "Test empty prefix path",
+			include: []string{""},
+			want:    branches,
+		},
+		{
+			name:    "Test nothing matches path",
+			include: []string{"test"},
+			want:    nil,
+		},
+		{
+			name:    "Test whitespace is trimmed path",
+			include: []string{" master ", " develop"},
+			want:    []string{"master", "develop"},
+		},
+		{
+			name:    "Test invalid include path",
+			include: []string{"re:(", "master"},
+			wantErr: true,
+		},
+		{
+			name:    "Test invalid exclude path",
+			include: []string{"master"},
+			exclude: []string{"re:[a-"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := Compile(tt.include, tt.exclude)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Compile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			var got []string
+			for _, branch := range branches {
+				if matcher.Match(branch) {
+					got = append(got, branch)
+				}
+			}
+
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("Matcher.Match() matched %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatcher_Select(t *testing.T) {
+	type selection struct {
+		Name    string
+		Pattern string
+	}
+
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		branches []string
+		want     []selection
+	}{
+		{
+			name:     "Test overlapping prefixes path",
+			include:  []string{"release", "release/2"},
+			branches: []string{"release/1.0", "release/2.0"},
+			want:     []selection{{"release/1.0", "release"}, {"release/2.0", "release"}},
+		},
+		{
+			name:     "Test first pattern takes precedence path",
+			include:  []string{"release/2*", "release/*"},
+			branches: []string{"release/1.0", "release/2.0"},
+			want:     []selection{{"release/1.0", "release/*"}, {"release/2.0", "release/2*"}},
+		},
+		{
+			name:     "Test empty prefix with another prefix path",
+			include:  []string{"master", ""},
+			branches: []string{"develop", "master"},
+			want:     []selection{{"develop", ""}, {"master", "master"}},
+		},
+		{
+			name:     "Test duplicate branch names path",
+			include:  []string{"master"},
+			branches: []string{"master", "master"},
+			want:     []selection{{"master", "master"}},
+		},
+		{
+			name:     "Test excluded path",
+			include:  []string{"release", "release/1*"},
+			exclude:  []string{"release/1.0"},
+			branches: []string{"release/1.0", "release/1.1"},
+			want:     []selection{{"release/1.1", "release"}},
+		},
+		{
+			name:     "Test only excludes path",
+			exclude:  []string{"develop"},
+			branches: []string{"develop", "master"},
+			want:     []selection{{"master", ""}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := Compile(tt.include, tt.exclude)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []selection
+			for _, s := range matcher.Select(tt.branches) {
+				got = append(got, selection{s.Name, s.Pattern.String()})
+			}
+
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("Matcher.Select() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatcher_Match_Nil(t *testing.T) {
+	var matcher *Matcher
+	if !matcher.Match("master") {
+		t.Error("A nil Matcher should match every branch")
+	}
+}
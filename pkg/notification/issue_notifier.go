@@ -0,0 +1,101 @@
+package notification
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aaron-vaz/github-branch-bot/pkg/github"
+)
+
+// DefaultIssueLabel labels the branch drift issues when no label is configured
+const DefaultIssueLabel = "branch-drift"
+
+const issueFooterText = "\n_This issue is updated on every branch check and closed once every branch is up to date._\n"
+
+// GitHubIssueNotifier keeps one labeled issue per repo describing the branches that are ahead of their base branch
+// the issue is updated on every run and closed once the repo is up to date
+type GitHubIssueNotifier struct {
+	API *github.APIService
+	// Label finds the issue of the repo, DefaultIssueLabel is used when it is empty
+	Label string
+}
+
+// Notify opens or updates the drift issue of the supplied repo with the branches that are ahead
+// the issue is closed when no branch is ahead, nothing is sent when the issue is already up to date
+func (n *GitHubIssueNotifier) Notify(owner, repo string, statuses []BranchStatus) error {
+	label := n.Label
+	if label == "" {
+		label = DefaultIssueLabel
+	}
+
+	issues, err := n.API.GetOpenIssues(owner, repo, label)
+	if err != nil {
+		return err
+	}
+
+	var existing *github.Issue
+	if len(issues) > 0 {
+		existing = &issues[0]
+	}
+
+	if len(statuses) == 0 {
+		if existing == nil {
+			return nil
+		}
+
+		log.Printf("Closing branch drift issue #%d of %s, every branch is up to date", existing.Number, repo)
+		_, err := n.API.UpdateIssue(owner, repo, existing.Number, github.Issue{Body: "Every branch is up to date.\n" + issueFooterText, State: "closed"})
+		return err
+	}
+
+	issue := GenerateDriftIssue(statuses)
+	if existing == nil {
+		issue.Labels = github.Labels{label}
+
+		log.Printf("Opening branch drift issue for %s", repo)
+		_, err := n.API.CreateIssue(owner, repo, issue)
+		return err
+	}
+
+	if existing.Title == issue.Title && existing.Body == issue.Body {
+		return nil
+	}
+
+	log.Printf("Updating branch drift issue #%d of %s", existing.Number, repo)
+	_, err = n.API.UpdateIssue(owner, repo, existing.Number, issue)
+	return err
+}
+
+// GenerateDriftIssue builds the title and body of the drift issue of a repo from the branches that are ahead
+func GenerateDriftIssue(statuses []BranchStatus) github.Issue {
+	var heads, bases []string
+	var lines []string
+	for _, status := range statuses {
+		heads = appendUnique(heads, status.Head)
+		bases = appendUnique(bases, status.Base)
+
+		line := fmt.Sprintf("- `%s` is ahead of `%s` by %d commits", status.Head, status.Base, status.Ahead)
+		if status.DaysAhead > 0 {
+			line += fmt.Sprintf(", ahead for %d days", status.DaysAhead)
+		}
+		if status.PullRequest != nil {
+			line += fmt.Sprintf(", PR #%d is open", status.PullRequest.Number)
+		}
+		lines = append(lines, line)
+	}
+
+	return github.Issue{
+		Title: fmt.Sprintf("Branch drift: %s ahead of %s", strings.Join(heads, ", "), strings.Join(bases, ", ")),
+		Body:  "These branches have commits their base branch does not have:\n\n" + strings.Join(lines, "\n") + "\n" + issueFooterText,
+	}
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,179 @@
+package notification
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aaron-vaz/github-branch-bot/pkg/github"
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeGitHubIssues is a github api fake keeping the issues of the org/test repo
+type fakeGitHubIssues struct {
+	issues   []github.Issue
+	requests []string
+	failList bool
+}
+
+func (f *fakeGitHubIssues) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	f.requests = append(f.requests, req.Method+" "+req.URL.Path)
+
+	switch {
+	case req.Method == http.MethodGet && req.URL.Path == "/repos/org/test/issues":
+		if f.failList {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		// github returns labels as objects
+		type label struct {
+			Name string `json:"name"`
+		}
+		var open []map[string]interface{}
+		for _, issue := range f.issues {
+			var labels []label
+			for _, name := range issue.Labels {
+				labels = append(labels, label{Name: name})
+			}
+			if issue.State == "open" && strings.Contains(strings.Join(issue.Labels, ","), req.URL.Query().Get("labels")) {
+				open = append(open, map[string]interface{}{"number": issue.Number, "title": issue.Title, "body": issue.Body, "state": issue.State, "labels": labels})
+			}
+		}
+		json.NewEncoder(rw).Encode(open)
+
+	case req.Method == http.MethodPost && req.URL.Path == "/repos/org/test/issues":
+		issue := github.Issue{}
+		json.NewDecoder(req.Body).Decode(&issue)
+		issue.Number, issue.State = len(f.issues)+1, "open"
+		f.issues = append(f.issues, issue)
+
+		rw.WriteHeader(http.StatusCreated)
+		json.NewEncoder(rw).Encode(issue)
+
+	case req.Method == http.MethodPatch && strings.HasPrefix(req.URL.Path, "/repos/org/test/issues/"):
+		number, _ := strconv.Atoi(strings.TrimPrefix(req.URL.Path, "/repos/org/test/issues/"))
+		if number < 1 || number > len(f.issues) {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		update := github.Issue{}
+		json.NewDecoder(req.Body).Decode(&update)
+		issue := &f.issues[number-1]
+		if update.Title != "" {
+			issue.Title = update.Title
+		}
+		if update.Body != "" {
+			issue.Body = update.Body
+		}
+		if update.State != "" {
+			issue.State = update.State
+		}
+		json.NewEncoder(rw).Encode(issue)
+
+	default:
+		rw.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestGitHubIssueNotifier_Notify(t *testing.T) {
+	ahead := []BranchStatus{
+		{Repo: "test", Head: "master", Base: "develop", Ahead: 3, DaysAhead: 4},
+		{Repo: "test", Head: "release", Base: "develop", Ahead: 1, PullRequest: &github.PullRequest{Number: 12}},
+	}
+	drift := GenerateDriftIssue(ahead)
+
+	tests := []struct {
+		name         string
+		issues       []github.Issue
+		failList     bool
+		statuses     []BranchStatus
+		wantRequests []string
+		wantIssues   []github.Issue
+		wantErr      bool
+	}{
+		{
+			name:         "Test open issue path",
+			statuses:     ahead,
+			wantRequests: []string{"GET /repos/org/test/issues", "POST /repos/org/test/issues"},
+			wantIssues:   []github.Issue{{Number: 1, Title: drift.Title, Body: drift.Body, State: "open", Labels: github.Labels{DefaultIssueLabel}}},
+		},
+		{
+			name:         "Test update issue path",
+			issues:       []github.Issue{{Number: 1, Title: "Branch drift: master ahead of develop", Body: "old", State: "open", Labels: github.Labels{DefaultIssueLabel}}},
+			statuses:     ahead,
+			wantRequests: []string{"GET /repos/org/test/issues", "PATCH /repos/org/test/issues/1"},
+			wantIssues:   []github.Issue{{Number: 1, Title: drift.Title, Body: drift.Body, State: "open", Labels: github.Labels{DefaultIssueLabel}}},
+		},
+		{
+			name:         "Test unchanged issue path",
+			issues:       []github.Issue{{Number: 1, Title: drift.Title, Body: drift.Body, State: "open", Labels: github.Labels{DefaultIssueLabel}}},
+			statuses:     ahead,
+			wantRequests: []string{"GET /repos/org/test/issues"},
+			wantIssues:   []github.Issue{{Number: 1, Title: drift.Title, Body: drift.Body, State: "open", Labels: github.Labels{DefaultIssueLabel}}},
+		},
+		{
+			name:         "Test close issue path",
+			issues:       []github.Issue{{Number: 1, Title: drift.Title, Body: drift.Body, State: "open", Labels: github.Labels{DefaultIssueLabel}}},
+			wantRequests: []string{"GET /repos/org/test/issues", "PATCH /repos/org/test/issues/1"},
+			wantIssues:   []github.Issue{{Number: 1, Title: drift.Title, Body: "Every branch is up to date.\n" + issueFooterText, State: "closed", Labels: github.Labels{DefaultIssueLabel}}},
+		},
+		{
+			name:         "Test up to date without issue path",
+			issues:       []github.Issue{{Number: 1, Title: "Closed drift", State: "closed", Labels: github.Labels{DefaultIssueLabel}}},
+			wantRequests: []string{"GET /repos/org/test/issues"},
+			wantIssues:   []github.Issue{{Number: 1, Title: "Closed drift", State: "closed", Labels: github.Labels{DefaultIssueLabel}}},
+		},
+		{
+			name:         "Test list failure path",
+			failList:     true,
+			statuses:     ahead,
+			wantRequests: []string{"GET /repos/org/test/issues"},
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeGitHubIssues{issues: tt.issues, failList: tt.failList}
+			server := httptest.NewServer(fake)
+			defer server.Close()
+
+			notifier := &GitHubIssueNotifier{API: &github.APIService{BaseURL: server.URL, Token: "token", Client: server.Client()}}
+			if err := notifier.Notify("org", "test", tt.statuses); (err != nil) != tt.wantErr {
+				t.Fatalf("GitHubIssueNotifier.Notify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !cmp.Equal(fake.requests, tt.wantRequests) {
+				t.Errorf("Unexpected github requests %s", cmp.Diff(tt.wantRequests, fake.requests))
+			}
+
+			if !cmp.Equal(fake.issues, tt.wantIssues) {
+				t.Errorf("Unexpected issues %s", cmp.Diff(tt.wantIssues, fake.issues))
+			}
+		})
+	}
+}
+
+func TestGenerateDriftIssue(t *testing.T) {
+	got := GenerateDriftIssue([]BranchStatus{
+		{Repo: "test", Head: "master", Base: "develop", Ahead: 3, DaysAhead: 4},
+		{Repo: "test", Head: "release", Base: "develop", Ahead: 1, PullRequest: &github.PullRequest{Number: 12}},
+		{Repo: "test", Head: "hotfix", Base: "main", Ahead: 2},
+	})
+
+	want := github.Issue{
+		Title: "Branch drift: master, release, hotfix ahead of develop, main",
+		Body: "These branches have commits their base branch does not have:\n\n" +
+			"- `master` is ahead of `develop` by 3 commits, ahead for 4 days\n" +
+			"- `release` is ahead of `develop` by 1 commits, PR #12 is open\n" +
+			"- `hotfix` is ahead of `main` by 2 commits\n" + issueFooterText,
+	}
+
+	if !cmp.Equal(got, want) {
+		t.Errorf("Unexpected drift issue %s", cmp.Diff(want, got))
+	}
+}
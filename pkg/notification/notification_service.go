@@ -8,40 +8,141 @@ import (
 	"log"
 	"net/http"
 	"sort"
+	"strings"
+	"sync"
+	"text/template"
 
+	"github.com/aaron-vaz/github-branch-bot/pkg/github"
 	"github.com/aaron-vaz/golang-utils/pkg/errorutil"
 	"github.com/aaron-vaz/golang-utils/pkg/ioutils"
 )
 
+// upToDateFooterText ends summaries that leave the up to date repos out
+const upToDateFooterText = "%d repos up to date\n"
+
+// defaultMessageTemplate is used for branches whose rule doesn't supply a template
+const defaultMessageTemplate = "{{with .Emoji}}{{.}} {{end}}{{.Head}} is ahead of {{.Base}} by {{.Ahead}} commits" +
+	"{{with .Change}} ({{printf \"%+d\" .}} since the last run){{end}}{{with .DaysAhead}}, ahead for {{.}} days{{end}}" +
+	"{{with .PullRequest}}, PR #{{.Number}} open for {{.DaysOpen}} days by @{{.User.Login}}{{end}}" +
+	"{{with .Mentions}} cc {{.}}{{end}}"
+
+var defaultMessage = template.Must(template.New("message").Parse(defaultMessageTemplate))
+
 // SlackService provides operations that allow you to post notifications to slack
 type SlackService struct {
 	Client *http.Client
+	// Users resolves the slack users mentioned in messages, when nil people are mentioned by their github login
+	Users *UserResolver
+	// Templates build the messages, the default templates are used when it is nil
+	Templates *Templates
+
+	// BaseURL, Token and Channel are the slack web api settings used to post details in a thread
+	// reports with details are sent with an attachment when they are not set
+	BaseURL string
+	Token   string
+	Channel string
 }
 
 // SlackMessage is used to build the message we will be posting to slack
 type SlackMessage struct {
-	Org      string
+	Org string
+	// Team names the org team the repos belong to, the summary is headed with the team when set
+	Team     string
 	Messages map[string][]string
+	// Bases records the base branch each repo was compared against, it is shown next to the repo when set
+	Bases map[string]string
+	// URLs records the github page of each repo
+	URLs map[string]string
+	// UpToDate records the repos that are up to date but left out of the summary, the summary ends with how many there are
+	UpToDate map[string][]string
+	// Templates build the summary and repo headers, the default templates are used when it is nil
+	Templates *Templates
+
+	mu sync.Mutex
+}
+
+// AddMessages records the messages for the supplied repo, it is safe to call from multiple goroutines
+// the base branch and url are only recorded when they are not empty
+func (sm *SlackMessage) AddMessages(repo, base, url string, messages []string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if sm.Messages == nil {
+		sm.Messages = make(map[string][]string)
+	}
+	sm.Messages[repo] = messages
+	sm.addRepo(repo, base, url)
+}
+
+// AddUpToDate records the up to date message of a repo that is left out of the summary, it is safe to call from multiple goroutines
+// the summary only shows how many repos are up to date, their messages are listed in the details
+func (sm *SlackMessage) AddUpToDate(repo, base, url, message string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if sm.UpToDate == nil {
+		sm.UpToDate = make(map[string][]string)
+	}
+	sm.UpToDate[repo] = []string{message}
+	sm.addRepo(repo, base, url)
+}
+
+func (sm *SlackMessage) addRepo(repo, base, url string) {
+	if base != "" {
+		if sm.Bases == nil {
+			sm.Bases = make(map[string]string)
+		}
+		sm.Bases[repo] = base
+	}
+
+	if url != "" {
+		if sm.URLs == nil {
+			sm.URLs = make(map[string]string)
+		}
+		sm.URLs[repo] = url
+	}
 }
 
 func (sm *SlackMessage) String() string {
-	if sm.Org == "" || len(sm.Messages) == 0 {
+	if sm.Org == "" || (len(sm.Messages) == 0 && len(sm.UpToDate) == 0) {
 		return ""
 	}
 
-	ret := fmt.Sprintf("*%s branch check summary:*\n", sm.Org)
-	ret += "\n"
+	templates := sm.templates()
+	ret := execute(templates.Summary, defaultTemplates.Summary, Summary{Org: sm.Org, Team: sm.Team}) + "\n\n"
+	ret += sm.repos(sm.Messages)
+
+	if len(sm.UpToDate) > 0 {
+		ret += fmt.Sprintf(upToDateFooterText, len(sm.UpToDate))
+	}
+
+	return ret
+}
+
+// Details lists the repos that are up to date but were left out of the summary, it is empty when there are none
+func (sm *SlackMessage) Details() string {
+	if sm.Org == "" {
+		return ""
+	}
+	return sm.repos(sm.UpToDate)
+}
 
+// repos lists the messages of the supplied repos in name order, each under its repo header
+func (sm *SlackMessage) repos(messages map[string][]string) string {
 	var repos []string
-	for repo := range sm.Messages {
+	for repo := range messages {
 		repos = append(repos, repo)
 	}
 
 	sort.Strings(repos)
 
+	templates := sm.templates()
+
+	var ret string
 	for _, repo := range repos {
-		ret += fmt.Sprintf("*%s*:\n", repo)
-		for _, message := range sm.Messages[repo] {
+		ret += execute(templates.Repo, defaultTemplates.Repo, RepoHeader{Repo: repo, Base: sm.Bases[repo], URL: sm.URLs[repo]}) + "\n"
+
+		for _, message := range messages[repo] {
 			ret += message
 			ret += "\n"
 		}
@@ -50,16 +151,148 @@ func (sm *SlackMessage) String() string {
 	return ret
 }
 
+func (sm *SlackMessage) templates() *Templates {
+	if sm.Templates == nil {
+		return defaultTemplates
+	}
+	return sm.Templates
+}
+
+// Report is a summary along with the webhook it is sent to
+type Report struct {
+	// WebhookURL is empty when the report is sent to the default webhook
+	WebhookURL string
+	Text       string
+	// Details are sent collapsed below the summary, such as the repos that are up to date, they are left out when empty
+	Details string
+	// Thread posts the details as a reply to the summary instead of an attachment, it needs the slack web api settings
+	Thread bool
+}
+
+// BranchStatus describes how far a head branch is ahead of its base branch, it is the data available to branch templates
+type BranchStatus struct {
+	// Owner is the org or user account the repo belongs to
+	Owner string
+	Repo  string
+	// Rule is the name of the branch rule that selected the branch, it is empty for the rule built from the head branch prefixes
+	Rule   string
+	Base   string
+	Head   string
+	Ahead  int
+	Behind int
+	// Commits are the commits Head has that Base does not
+	Commits []github.Commit
+	// CodeOwners are the CODEOWNERS of the files Head changes, they are only loaded when codeowners are mentioned
+	CodeOwners []string
+	// URL is the github page of Head
+	URL string
+	// CompareURL is the github page comparing Head with Base
+	CompareURL string
+	// PullRequest is the open pull request from Head into Base, it is nil when there is none
+	PullRequest *github.PullRequest
+	// DaysAhead is how many days the branch has been ahead of Base, it is only known when state is kept between runs
+	DaysAhead int
+	// Change is how many commits the branch has gained or lost since the last run, it is 0 when it was not ahead then
+	Change int
+	// Mentions are the slack mentions of the people responsible for the branch, it is empty when mentions are off
+	Mentions string
+	// Severity is one of info, warning or critical, it is info when no severity thresholds apply to the branch
+	Severity string
+	// Emoji marks the severity of the branch, it is empty when no severity thresholds apply to the branch
+	Emoji string
+}
+
 // GenerateMessage build a mesage that will be posted to the slack channel
-func (service *SlackService) GenerateMessage(repo, base, head string, aheadBy int) string {
-	var message string
+// the message is built from the supplied text/template, the branch template is used when no template is supplied
+func (service *SlackService) GenerateMessage(status BranchStatus, messageTemplate string) string {
+	if status.Ahead <= 0 {
+		return ""
+	}
+
+	log.Printf("%s branch %s is ahead of %s", status.Repo, status.Head, status.Base)
+
+	tmpl := service.templates().Branch
+	if messageTemplate != "" {
+		custom, err := template.New("message").Parse(messageTemplate)
+		errorutil.ErrCheck(err, false)
 
-	if aheadBy > 0 {
-		log.Printf("%s branch %s is ahead of %s", repo, head, base)
-		message += fmt.Sprintf("%s is ahead of %s by %d commits\n", head, base, aheadBy)
+		if err == nil {
+			tmpl = custom
+		}
+	}
+
+	return execute(tmpl, defaultMessage, status) + "\n"
+}
+
+// FormatMentions builds the slack mentions of the supplied people
+// people resolved to a slack id are mentioned by id, the others by their github login or team, or their email when it is all that is known
+func (service *SlackService) FormatMentions(people []Person) string {
+	var mentions []string
+	for _, person := range people {
+		switch id := service.Users.Resolve(person); {
+		case strings.HasPrefix(id, "S"):
+			mentions = append(mentions, fmt.Sprintf("<!subteam^%s>", id))
+		case id != "":
+			mentions = append(mentions, fmt.Sprintf("<@%s>", id))
+		case person.Login != "":
+			mentions = append(mentions, "@"+person.Login)
+		case person.Email != "":
+			mentions = append(mentions, person.Email)
+		}
 	}
 
-	return message
+	return strings.Join(mentions, " ")
+}
+
+// GenerateEscalationMessage build a message escalating a branch that has been ahead of its base branch for too long
+func (service *SlackService) GenerateEscalationMessage(owner string, status BranchStatus, level int) string {
+	log.Printf("%s branch %s reached escalation level %d", status.Repo, status.Head, level)
+
+	message := fmt.Sprintf("*%s/%s*: %s has been ahead of %s by %d commits for %d days (escalation level %d)", owner, status.Repo, status.Head, status.Base, status.Ahead, status.DaysAhead, level)
+	if status.Mentions != "" {
+		message += " cc " + status.Mentions
+	}
+
+	return message + "\n"
+}
+
+// GenerateEscalationIssue build the github issue opened when a branch has been ahead of its base branch for too long
+func (service *SlackService) GenerateEscalationIssue(status BranchStatus, labels []string) github.Issue {
+	return github.Issue{
+		Title: fmt.Sprintf("Branch drift: %s ahead of %s", status.Head, status.Base),
+		Body: fmt.Sprintf("%s has been ahead of %s by %d commits for %d days, merge it into %s or delete it if it is no longer needed.\n",
+			status.Head, status.Base, status.Ahead, status.DaysAhead, status.Base),
+		Labels: labels,
+	}
+}
+
+// GenerateResolvedMessage build a message recording that a branch that was ahead of its base branch in the last run is now up to date
+func (service *SlackService) GenerateResolvedMessage(repo, head, base string) string {
+	log.Printf("%s branch %s is now up to date with %s", repo, head, base)
+	return fmt.Sprintf("%s is now up to date with %s\n", head, base)
+}
+
+// GenerateStaleMessage build a message describing a branch that has had no activity for the supplied number of days
+func (service *SlackService) GenerateStaleMessage(repo string, branch *github.Branch, days int) string {
+	log.Printf("%s branch %s has had no activity for %d days", repo, branch.Name, days)
+	return fmt.Sprintf("%s has had no activity for %d days, last commit %s by %s\n", branch.Name, days, shortSHA(branch.Commit.SHA), branch.Commit.AuthorName())
+}
+
+// GenerateDeletionMessage build a message recording the deletion of a stale branch
+// the full commit sha is included so the branch can be restored
+func (service *SlackService) GenerateDeletionMessage(repo string, branch *github.Branch, dryRun bool, err error) string {
+	switch {
+	case dryRun:
+		return fmt.Sprintf("would delete stale branch %s at %s\n", branch.Name, branch.Commit.SHA)
+
+	case err != nil:
+		log.Printf("Failed to delete %s branch %s: %v", repo, branch.Name, err)
+		return fmt.Sprintf("failed to delete stale branch %s at %s\n", branch.Name, branch.Commit.SHA)
+
+	default:
+		log.Printf("Deleted %s branch %s at %s", repo, branch.Name, branch.Commit.SHA)
+		return fmt.Sprintf("deleted stale branch %s at %s\n", branch.Name, branch.Commit.SHA)
+	}
 }
 
 // Notify sends slack message in the form of a json payload to the URL provided
@@ -69,7 +302,12 @@ func (service *SlackService) Notify(url, message string) {
 		return
 	}
 
-	payload, err := json.Marshal(map[string]string{"text": message})
+	service.post(url, map[string]string{"text": message})
+}
+
+// post sends the supplied json payload to the URL provided
+func (service *SlackService) post(url string, message interface{}) {
+	payload, err := json.Marshal(message)
 	errorutil.ErrCheck(err, false)
 
 	res, err := service.Client.Post(url, "application/json", bytes.NewReader(payload))
@@ -84,3 +322,80 @@ func (service *SlackService) Notify(url, message string) {
 
 	log.Printf("Slack response: %s", body)
 }
+
+// Send posts the supplied report to the URL provided, its details are collapsed into a thread or an attachment
+// threads are posted to the channel with the slack web api, the URL is only used when the report has no thread
+func (service *SlackService) Send(url string, report Report) {
+	switch {
+	case report.Details == "":
+		service.Notify(url, report.Text)
+
+	case report.Thread && service.Token != "" && service.Channel != "":
+		if err := service.postThread(report.Text, report.Details); err != nil {
+			log.Printf("Could not post the report in a thread: %v", err)
+		}
+
+	default:
+		// slack collapses long attachments behind a show more link
+		service.post(url, map[string]interface{}{
+			"text":        report.Text,
+			"attachments": []map[string]string{{"fallback": report.Details, "text": report.Details}},
+		})
+	}
+}
+
+// postThread posts the supplied message to the channel and the details as a reply to it
+func (service *SlackService) postThread(message, details string) error {
+	ts, err := service.postMessage(map[string]string{"channel": service.Channel, "text": message})
+	if err != nil {
+		return err
+	}
+
+	_, err = service.postMessage(map[string]string{"channel": service.Channel, "text": details, "thread_ts": ts})
+	return err
+}
+
+// postMessage posts a message with chat.postMessage and returns its timestamp
+func (service *SlackService) postMessage(message map[string]string) (string, error) {
+	baseURL := service.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultSlackAPIURL
+	}
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(baseURL, "/")+postMessagePath, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Add("Content-Type", "application/json; charset=utf-8")
+	req.Header.Add("Authorization", "Bearer "+service.Token)
+
+	res, err := service.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer ioutils.Close(res.Body)
+
+	response := &postMessageResponse{}
+	if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+		return "", err
+	}
+
+	if !response.OK {
+		return "", fmt.Errorf("slack chat.postMessage failed: %s", response.Error)
+	}
+
+	return response.TS, nil
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -1,11 +1,18 @@
 package notification
 
 import (
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/aaron-vaz/github-branch-bot/pkg/github"
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestSlackService_Notify(t *testing.T) {
@@ -45,7 +52,7 @@ func TestSlackService_Notify(t *testing.T) {
 				received = true
 			}))
 
-			service := &SlackService{server.Client()}
+			service := &SlackService{Client: server.Client()}
 			service.Notify(server.URL, tt.message)
 
 			if received != tt.delivered {
@@ -56,44 +63,175 @@ func TestSlackService_Notify(t *testing.T) {
 }
 
 func TestSlackService_GenerateMessage(t *testing.T) {
-	type args struct {
-		repo    string
-		base    string
-		head    string
-		aheadBy int
+	pull := openPullRequest(123, "alice", 3)
+
+	tests := []struct {
+		name     string
+		status   BranchStatus
+		template string
+		want     string
+	}{
+		{
+			name:   "Test Happy Path",
+			status: BranchStatus{Repo: "test", Base: "develop", Head: "master", Ahead: 5},
+			want:   "master is ahead of develop by 5 commits\n",
+		},
+
+		{
+			name:   "Test branches up to date path",
+			status: BranchStatus{Repo: "test", Base: "develop", Head: "master", Ahead: 0},
+			want:   "",
+		},
+
+		{
+			name:   "Test open pull request path",
+			status: BranchStatus{Repo: "test", Base: "develop", Head: "master", Ahead: 4, PullRequest: &pull},
+			want:   "master is ahead of develop by 4 commits, PR #123 open for 3 days by @alice\n",
+		},
+
+		{
+			name:   "Test age and change path",
+			status: BranchStatus{Repo: "test", Base: "develop", Head: "master", Ahead: 7, DaysAhead: 5, Change: 3},
+			want:   "master is ahead of develop by 7 commits (+3 since the last run), ahead for 5 days\n",
+		},
+
+		{
+			name:   "Test open pull request up to date path",
+			status: BranchStatus{Repo: "test", Base: "develop", Head: "master", Ahead: 0, PullRequest: &pull},
+			want:   "",
+		},
+
+		{
+			name:     "Test rule template path",
+			status:   BranchStatus{Repo: "test", Rule: "release", Base: "main", Head: "release/1.0", Ahead: 2},
+			template: "{{.Rule}}: {{.Head}} needs merging into {{.Base}} ({{.Ahead}})",
+			want:     "release: release/1.0 needs merging into main (2)\n",
+		},
+
+		{
+			name:     "Test invalid template path",
+			status:   BranchStatus{Repo: "test", Base: "develop", Head: "master", Ahead: 5},
+			template: "{{.Head",
+			want:     "master is ahead of develop by 5 commits\n",
+		},
+
+		{
+			name:     "Test template execution error path",
+			status:   BranchStatus{Repo: "test", Base: "develop", Head: "master", Ahead: 5},
+			template: "{{.Missing}}",
+			want:     "master is ahead of develop by 5 commits\n",
+		},
 	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &SlackService{}
+			if got := service.GenerateMessage(tt.status, tt.template); got != tt.want {
+				t.Errorf("SlackService.GenerateMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlackService_GenerateStaleMessage(t *testing.T) {
+	branch := &github.Branch{Name: "release/1.0"}
+	branch.Commit.SHA = "7fd1a60b01f91b314f59955a4e4d4e80d8edf11d"
+	branch.Commit.Author.Login = "octocat"
+
+	unknownAuthor := &github.Branch{Name: "hotfix"}
+	unknownAuthor.Commit.SHA = "7fd1a60"
+	unknownAuthor.Commit.Commit.Author.Name = "The Octocat"
+
 	tests := []struct {
-		name string
-		args args
-		want string
+		name   string
+		branch *github.Branch
+		days   int
+		want   string
 	}{
 		{
-			name: "Test Happy Path",
-			args: args{
-				repo:    "test",
-				base:    "develop",
-				head:    "master",
-				aheadBy: 5,
-			},
-			want: "master is ahead of develop by 5 commits\n",
+			name:   "Test Happy Path",
+			branch: branch,
+			days:   30,
+			want:   "release/1.0 has had no activity for 30 days, last commit 7fd1a60 by octocat\n",
 		},
+		{
+			name:   "Test author without github login path",
+			branch: unknownAuthor,
+			days:   90,
+			want:   "hotfix has had no activity for 90 days, last commit 7fd1a60 by The Octocat\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &SlackService{}
+			if got := service.GenerateStaleMessage("test", tt.branch, tt.days); got != tt.want {
+				t.Errorf("SlackService.GenerateStaleMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlackService_FormatMentions(t *testing.T) {
+	users := &UserResolver{Overrides: map[string]string{"octocat": "U123", "org/platform": "S456", "docs@example.com": "U789"}}
 
+	tests := []struct {
+		name   string
+		people []Person
+		want   string
+	}{
 		{
-			name: "Test branches up to date path",
-			args: args{
-				repo:    "test",
-				base:    "develop",
-				head:    "master",
-				aheadBy: 0,
-			},
-			want: "",
+			name:   "Test resolved people path",
+			people: []Person{{Login: "octocat"}, {Login: "org/platform"}, {Email: "docs@example.com"}},
+			want:   "<@U123> <!subteam^S456> <@U789>",
+		},
+		{
+			name:   "Test unresolved people path",
+			people: []Person{{Login: "someone", Email: "someone@example.com"}, {Login: "org/ci"}, {Email: "team@example.com"}},
+			want:   "@someone @org/ci team@example.com",
+		},
+		{
+			name: "Test no people path",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &SlackService{Users: users}
+			if got := service.FormatMentions(tt.people); got != tt.want {
+				t.Errorf("SlackService.FormatMentions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlackService_GenerateDeletionMessage(t *testing.T) {
+	branch := &github.Branch{Name: "release/1.0"}
+	branch.Commit.SHA = "7fd1a60b01f91b314f59955a4e4d4e80d8edf11d"
+
+	tests := []struct {
+		name   string
+		dryRun bool
+		err    error
+		want   string
+	}{
+		{
+			name: "Test Happy Path",
+			want: "deleted stale branch release/1.0 at 7fd1a60b01f91b314f59955a4e4d4e80d8edf11d\n",
+		},
+		{
+			name:   "Test dry run path",
+			dryRun: true,
+			want:   "would delete stale branch release/1.0 at 7fd1a60b01f91b314f59955a4e4d4e80d8edf11d\n",
+		},
+		{
+			name: "Test failed deletion path",
+			err:  errors.New("422 Unprocessable Entity"),
+			want: "failed to delete stale branch release/1.0 at 7fd1a60b01f91b314f59955a4e4d4e80d8edf11d\n",
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			service := &SlackService{}
-			if got := service.GenerateMessage(tt.args.repo, tt.args.base, tt.args.head, tt.args.aheadBy); got != tt.want {
-				t.Errorf("SlackService.GenerateMessage() = %v, want %v", got, tt.want)
+			if got := service.GenerateDeletionMessage("test", branch, tt.dryRun, tt.err); got != tt.want {
+				t.Errorf("SlackService.GenerateDeletionMessage() = %q, want %q", got, tt.want)
 			}
 		})
 	}
@@ -121,6 +259,32 @@ func TestSlackMessage_String(t *testing.T) {
 			},
 			want: "*Organisation branch check summary:*\n\n*test repo*:\nmessage 1\n",
 		},
+		{
+			name: "Test base branch path",
+			sm: &SlackMessage{
+				Org:      "Organisation",
+				Messages: map[string][]string{"test repo": []string{"message 1"}, "other repo": []string{"message 2"}},
+				Bases:    map[string]string{"test repo": "main"},
+			},
+			want: "*Organisation branch check summary:*\n\n*other repo*:\nmessage 2\n*test repo* (base main):\nmessage 1\n",
+		},
+		{
+			name: "Test up to date footer path",
+			sm: &SlackMessage{
+				Org:      "Organisation",
+				Messages: map[string][]string{"test repo": []string{"message 1"}},
+				UpToDate: map[string][]string{"other repo": []string{"up to date with main\n"}, "third repo": []string{"up to date with main\n"}},
+			},
+			want: "*Organisation branch check summary:*\n\n*test repo*:\nmessage 1\n2 repos up to date\n",
+		},
+		{
+			name: "Test only up to date path",
+			sm: &SlackMessage{
+				Org:      "Organisation",
+				UpToDate: map[string][]string{"other repo": []string{"up to date with main\n"}},
+			},
+			want: "*Organisation branch check summary:*\n\n1 repos up to date\n",
+		},
 		{
 			name: "Test no org path",
 			sm: &SlackMessage{
@@ -146,3 +310,117 @@ func TestSlackMessage_String(t *testing.T) {
 		})
 	}
 }
+
+func openPullRequest(number int, login string, days int) github.PullRequest {
+	pull := github.PullRequest{Number: number, CreatedAt: time.Now().Add(-time.Duration(days) * 24 * time.Hour)}
+	pull.User.Login = login
+
+	return pull
+}
+
+func TestSlackMessage_AddMessages(t *testing.T) {
+	sm := &SlackMessage{Org: "Organisation"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sm.AddMessages(fmt.Sprintf("repo %d", i), "", "", []string{"message"})
+		}(i)
+	}
+	wg.Wait()
+
+	sm.AddMessages("repo 0", "main", "https://github.com/org/repo-0", []string{"message 1"})
+
+	if len(sm.Messages) != 10 {
+		t.Errorf("Unexpected number of repos, want = 10, got = %d", len(sm.Messages))
+	}
+
+	if got := sm.Messages["repo 0"]; !cmp.Equal(got, []string{"message 1"}) {
+		t.Errorf("Unexpected messages for repo 0, want = [message 1], got = %v", got)
+	}
+
+	if !cmp.Equal(sm.Bases, map[string]string{"repo 0": "main"}) {
+		t.Errorf("Unexpected bases, want = map[repo 0:main], got = %v", sm.Bases)
+	}
+
+	if !cmp.Equal(sm.URLs, map[string]string{"repo 0": "https://github.com/org/repo-0"}) {
+		t.Errorf("Unexpected urls, want = map[repo 0:https://github.com/org/repo-0], got = %v", sm.URLs)
+	}
+}
+
+func TestSlackMessage_Details(t *testing.T) {
+	sm := &SlackMessage{Org: "Organisation"}
+	sm.AddMessages("test repo", "", "", []string{"message 1"})
+	sm.AddUpToDate("other repo", "main", "", "up to date with main")
+
+	if got, want := sm.Details(), "*other repo* (base main):\nup to date with main\n"; got != want {
+		t.Errorf("SlackMessage.Details() = %q, want %q", got, want)
+	}
+
+	if got := (&SlackMessage{Org: "Organisation"}).Details(); got != "" {
+		t.Errorf("SlackMessage.Details() = %q, want empty details", got)
+	}
+}
+
+func TestSlackService_Send(t *testing.T) {
+	tests := []struct {
+		name     string
+		report   Report
+		token    string
+		channel  string
+		expected []string
+	}{
+		{
+			name:     "Test no details path",
+			report:   Report{Text: "summary"},
+			expected: []string{`/webhook {"text":"summary"}`},
+		},
+		{
+			name:     "Test attachment path",
+			report:   Report{Text: "summary", Details: "details"},
+			expected: []string{`/webhook {"attachments":[{"fallback":"details","text":"details"}],"text":"summary"}`},
+		},
+		{
+			name:    "Test thread path",
+			report:  Report{Text: "summary", Details: "details", Thread: true},
+			token:   "token",
+			channel: "C1234",
+			expected: []string{
+				`/chat.postMessage {"channel":"C1234","text":"summary"}`,
+				`/chat.postMessage {"channel":"C1234","text":"details","thread_ts":"1234.5678"}`,
+			},
+		},
+		{
+			name:     "Test thread without token path",
+			report:   Report{Text: "summary", Details: "details", Thread: true},
+			channel:  "C1234",
+			expected: []string{`/webhook {"attachments":[{"fallback":"details","text":"details"}],"text":"summary"}`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received []string
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				body, _ := ioutil.ReadAll(req.Body)
+				received = append(received, req.URL.Path+" "+strings.TrimSpace(string(body)))
+
+				if req.URL.Path == postMessagePath {
+					if req.Header.Get("Authorization") != "Bearer "+tt.token {
+						t.Errorf("Unexpected authorization header %q", req.Header.Get("Authorization"))
+					}
+					rw.Write([]byte(`{"ok": true, "ts": "1234.5678"}`))
+				}
+			}))
+			defer server.Close()
+
+			service := &SlackService{Client: server.Client(), BaseURL: server.URL, Token: tt.token, Channel: tt.channel}
+			service.Send(server.URL+"/webhook", tt.report)
+
+			if !cmp.Equal(received, tt.expected) {
+				t.Errorf("Unexpected slack requests %s", cmp.Diff(tt.expected, received))
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package notification
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aaron-vaz/github-branch-bot/pkg/github"
+)
+
+// DefaultStatusContext names the commit statuses when no context is configured
+const DefaultStatusContext = "branch-bot"
+
+// maxStatusDescription is the longest commit status description github accepts
+const maxStatusDescription = 140
+
+// CommitStatusNotifier sets a commit status on the head commit of a base branch listing the branches that are ahead of it
+// so branch protection rules and dashboards can react to drift, commit statuses work with tokens as well as github apps
+type CommitStatusNotifier struct {
+	API *github.APIService
+	// Context names the status, DefaultStatusContext is used when it is empty
+	Context string
+	// Threshold fails the status when a branch is ahead by at least this many commits, 0 never fails it
+	Threshold int
+}
+
+// Notify sets the status of the head commit of the supplied base branch from the branches that are ahead of it
+func (n *CommitStatusNotifier) Notify(owner, repo, base string, statuses []BranchStatus) error {
+	branch, err := n.API.GetBranch(owner, repo, base)
+	if err != nil {
+		return err
+	}
+
+	if branch.Commit.SHA == "" {
+		return fmt.Errorf("could not find the head commit of %s", base)
+	}
+
+	status := GenerateCommitStatus(base, statuses, n.Threshold)
+	status.Context = n.Context
+	if status.Context == "" {
+		status.Context = DefaultStatusContext
+	}
+
+	log.Printf("Setting %s status of %s %s at %s: %s", status.State, repo, base, branch.Commit.SHA, status.Description)
+	return n.API.CreateStatus(owner, repo, branch.Commit.SHA, status)
+}
+
+// GenerateCommitStatus builds the commit status of a base branch from the branches that are ahead of it
+// it fails when a branch is ahead by at least the supplied threshold, a threshold of 0 never fails it
+func GenerateCommitStatus(base string, statuses []BranchStatus, threshold int) github.Status {
+	if len(statuses) == 0 {
+		return github.Status{State: github.StatusSuccess, Description: fmt.Sprintf("no branches are ahead of %s", base)}
+	}
+
+	state := github.StatusSuccess
+	var parts []string
+	for _, status := range statuses {
+		parts = append(parts, fmt.Sprintf("%s is %d commits ahead", status.Head, status.Ahead))
+		if threshold > 0 && status.Ahead >= threshold {
+			state = github.StatusFailure
+		}
+	}
+
+	description := strings.Join(parts, ", ")
+	if len(description) > maxStatusDescription {
+		description = description[:maxStatusDescription-3] + "..."
+	}
+
+	return github.Status{State: state, Description: description}
+}
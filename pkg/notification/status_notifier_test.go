@@ -0,0 +1,96 @@
+package notification
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aaron-vaz/github-branch-bot/pkg/github"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCommitStatusNotifier_Notify(t *testing.T) {
+	tests := []struct {
+		name       string
+		branch     string
+		context    string
+		threshold  int
+		statuses   []BranchStatus
+		wantStatus *github.Status
+		wantErr    bool
+	}{
+		{
+			name:       "Test up to date path",
+			branch:     `{"name": "develop", "commit": {"sha": "6dcb09b"}}`,
+			wantStatus: &github.Status{State: github.StatusSuccess, Description: "no branches are ahead of develop", Context: DefaultStatusContext},
+		},
+		{
+			name:       "Test below threshold path",
+			branch:     `{"name": "develop", "commit": {"sha": "6dcb09b"}}`,
+			context:    "drift",
+			threshold:  5,
+			statuses:   []BranchStatus{{Head: "master", Base: "develop", Ahead: 3}},
+			wantStatus: &github.Status{State: github.StatusSuccess, Description: "master is 3 commits ahead", Context: "drift"},
+		},
+		{
+			name:       "Test above threshold path",
+			branch:     `{"name": "develop", "commit": {"sha": "6dcb09b"}}`,
+			threshold:  3,
+			statuses:   []BranchStatus{{Head: "master", Base: "develop", Ahead: 3}, {Head: "release", Base: "develop", Ahead: 1}},
+			wantStatus: &github.Status{State: github.StatusFailure, Description: "master is 3 commits ahead, release is 1 commits ahead", Context: DefaultStatusContext},
+		},
+		{
+			name:     "Test missing base branch path",
+			branch:   `{"message": "Branch not found"}`,
+			statuses: []BranchStatus{{Head: "master", Base: "develop", Ahead: 3}},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *github.Status
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				switch {
+				case req.Method == http.MethodGet && req.URL.Path == "/repos/org/test/branches/develop":
+					rw.Write([]byte(tt.branch))
+
+				case req.Method == http.MethodPost && req.URL.Path == "/repos/org/test/statuses/6dcb09b":
+					got = &github.Status{}
+					json.NewDecoder(req.Body).Decode(got)
+					rw.WriteHeader(http.StatusCreated)
+
+				default:
+					rw.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			notifier := &CommitStatusNotifier{API: &github.APIService{BaseURL: server.URL, Token: "token", Client: server.Client()}, Context: tt.context, Threshold: tt.threshold}
+			if err := notifier.Notify("org", "test", "develop", tt.statuses); (err != nil) != tt.wantErr {
+				t.Fatalf("CommitStatusNotifier.Notify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !cmp.Equal(got, tt.wantStatus) {
+				t.Errorf("Unexpected commit status %s", cmp.Diff(tt.wantStatus, got))
+			}
+		})
+	}
+}
+
+func TestGenerateCommitStatus_LongDescription(t *testing.T) {
+	var statuses []BranchStatus
+	for i := 0; i < 10; i++ {
+		statuses = append(statuses, BranchStatus{Head: "feature/branch-" + strings.Repeat("x", i), Base: "develop", Ahead: i + 1})
+	}
+
+	got := GenerateCommitStatus("develop", statuses, 0)
+	if len(got.Description) != maxStatusDescription || !strings.HasSuffix(got.Description, "...") {
+		t.Errorf("Unexpected commit status description %q", got.Description)
+	}
+
+	if got.State != github.StatusSuccess {
+		t.Errorf("Unexpected commit status state %s, a threshold of 0 never fails", got.State)
+	}
+}
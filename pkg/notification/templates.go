@@ -0,0 +1,121 @@
+package notification
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/aaron-vaz/golang-utils/pkg/errorutil"
+)
+
+// Default templates, they build the messages the bot sends when no templates are configured
+const (
+	// DefaultSummaryTemplate heads every report, it is executed with a Summary
+	DefaultSummaryTemplate = "*{{.Org}}{{with .Team}} team {{.}}{{end}} branch check summary:*"
+	// DefaultRepoTemplate heads the messages of each repo, it is executed with a RepoHeader
+	DefaultRepoTemplate = "*{{.Repo}}*{{with .Base}} (base {{.}}){{end}}:"
+	// DefaultBranchTemplate describes each branch that is ahead, it is executed with a BranchStatus
+	DefaultBranchTemplate = defaultMessageTemplate
+	// DefaultUpToDateTemplate describes a repo whose branches are all up to date, it is executed with an UpToDate
+	DefaultUpToDateTemplate = "up to date with {{.Base}}"
+)
+
+var defaultTemplates = &Templates{
+	Summary:  template.Must(template.New("summary").Parse(DefaultSummaryTemplate)),
+	Repo:     template.Must(template.New("repo").Parse(DefaultRepoTemplate)),
+	Branch:   defaultMessage,
+	UpToDate: template.Must(template.New("up_to_date").Parse(DefaultUpToDateTemplate)),
+}
+
+// Templates build the summary header, repo headers, branch lines and up to date lines of the reports
+type Templates struct {
+	Summary  *template.Template
+	Repo     *template.Template
+	Branch   *template.Template
+	UpToDate *template.Template
+}
+
+// NewTemplates parses the supplied text/templates, the default template is used for the ones that are empty
+func NewTemplates(summary, repo, branch, upToDate string) (*Templates, error) {
+	templates := *defaultTemplates
+
+	for _, t := range []struct {
+		name string
+		text string
+		dest **template.Template
+	}{
+		{"summary", summary, &templates.Summary},
+		{"repo", repo, &templates.Repo},
+		{"branch", branch, &templates.Branch},
+		{"up_to_date", upToDate, &templates.UpToDate},
+	} {
+		if t.text == "" {
+			continue
+		}
+
+		parsed, err := template.New(t.name).Parse(t.text)
+		if err != nil {
+			return nil, err
+		}
+		*t.dest = parsed
+	}
+
+	return &templates, nil
+}
+
+// Summary is the data available to the summary template
+type Summary struct {
+	// Org lists the owners covered by the report
+	Org string
+	// Team is the org team the report covers, it is empty when the report is not grouped by team
+	Team string
+}
+
+// RepoHeader is the data available to the repo template
+type RepoHeader struct {
+	// Repo is the name the repo is listed under, it is prefixed with its owner when several owners share a report
+	Repo string
+	// Base is the branch the repo was compared against, it is only set when bases can differ between repos
+	Base string
+	// URL is the github page of the repo
+	URL string
+}
+
+// UpToDate is the data available to the up to date template
+type UpToDate struct {
+	Repo string
+	// Bases are the branches the repo was compared against
+	Bases []string
+	// Base lists the bases separated by commas
+	Base string
+}
+
+// execute runs the supplied template, the default template is used when it fails so a broken template does not lose the report
+func execute(tmpl, fallback *template.Template, data interface{}) string {
+	var text strings.Builder
+	if tmpl != nil {
+		err := tmpl.Execute(&text, data)
+		if err == nil {
+			return text.String()
+		}
+
+		errorutil.ErrCheck(err, false)
+		text.Reset()
+	}
+
+	errorutil.ErrCheck(fallback.Execute(&text, data), false)
+	return text.String()
+}
+
+// templates returns the configured templates, the defaults when none are configured
+func (service *SlackService) templates() *Templates {
+	if service == nil || service.Templates == nil {
+		return defaultTemplates
+	}
+	return service.Templates
+}
+
+// GenerateUpToDateMessage build the message for a repo whose branches are all up to date with their bases
+func (service *SlackService) GenerateUpToDateMessage(repo string, bases []string) string {
+	data := UpToDate{Repo: repo, Bases: bases, Base: strings.Join(bases, ", ")}
+	return execute(service.templates().UpToDate, defaultTemplates.UpToDate, data) + "\n"
+}
@@ -0,0 +1,77 @@
+package notification
+
+import (
+	"testing"
+)
+
+func TestNewTemplates(t *testing.T) {
+	templates, err := NewTemplates(
+		"*Branch drift in {{.Org}}*",
+		"<{{.URL}}|{{.Repo}}>",
+		"{{.Head}} is {{.Ahead}} ahead and {{.Behind}} behind {{.Base}}, {{len .Commits}} commits <{{.CompareURL}}|compare>",
+		"{{.Repo}} is all caught up with {{.Base}}",
+	)
+	if err != nil {
+		t.Fatalf("NewTemplates() unexpected error = %v", err)
+	}
+
+	service := &SlackService{Templates: templates}
+	status := BranchStatus{Repo: "test", Head: "master", Base: "develop", Ahead: 2, Behind: 1, CompareURL: "https://github.com/org/test/compare/develop...master"}
+	if got, want := service.GenerateMessage(status, ""), "master is 2 ahead and 1 behind develop, 0 commits <https://github.com/org/test/compare/develop...master|compare>\n"; got != want {
+		t.Errorf("SlackService.GenerateMessage() = %q, want %q", got, want)
+	}
+
+	// the template of a rule replaces the branch template
+	if got, want := service.GenerateMessage(status, "{{.Head}} needs merging"), "master needs merging\n"; got != want {
+		t.Errorf("SlackService.GenerateMessage() = %q, want %q", got, want)
+	}
+
+	if got, want := service.GenerateUpToDateMessage("test", []string{"develop", "main"}), "test is all caught up with develop, main\n"; got != want {
+		t.Errorf("SlackService.GenerateUpToDateMessage() = %q, want %q", got, want)
+	}
+
+	sm := &SlackMessage{
+		Org:       "org",
+		Messages:  map[string][]string{"test": {"message"}},
+		URLs:      map[string]string{"test": "https://github.com/org/test"},
+		Templates: templates,
+	}
+	if got, want := sm.String(), "*Branch drift in org*\n\n<https://github.com/org/test|test>\nmessage\n"; got != want {
+		t.Errorf("SlackMessage.String() = %q, want %q", got, want)
+	}
+}
+
+func TestNewTemplates_Defaults(t *testing.T) {
+	templates, err := NewTemplates("", "", "", "")
+	if err != nil {
+		t.Fatalf("NewTemplates() unexpected error = %v", err)
+	}
+
+	service := &SlackService{Templates: templates}
+	if got, want := service.GenerateUpToDateMessage("test", []string{"develop"}), "up to date with develop\n"; got != want {
+		t.Errorf("SlackService.GenerateUpToDateMessage() = %q, want %q", got, want)
+	}
+
+	sm := &SlackMessage{Org: "org", Team: "payments", Messages: map[string][]string{"test": {"message"}}, Bases: map[string]string{"test": "main"}, Templates: templates}
+	if got, want := sm.String(), "*org team payments branch check summary:*\n\n*test* (base main):\nmessage\n"; got != want {
+		t.Errorf("SlackMessage.String() = %q, want %q", got, want)
+	}
+
+	if _, err := NewTemplates("{{.Org", "", "", ""); err == nil {
+		t.Errorf("NewTemplates() expected an error for an unclosed action")
+	}
+}
+
+func TestSlackService_GenerateMessage_BrokenTemplate(t *testing.T) {
+	templates, err := NewTemplates("", "", "{{.Missing}}", "")
+	if err != nil {
+		t.Fatalf("NewTemplates() unexpected error = %v", err)
+	}
+
+	// templates that fail to execute fall back to the default wording
+	service := &SlackService{Templates: templates}
+	status := BranchStatus{Repo: "test", Head: "master", Base: "develop", Ahead: 1}
+	if got, want := service.GenerateMessage(status, ""), "master is ahead of develop by 1 commits\n"; got != want {
+		t.Errorf("SlackService.GenerateMessage() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,142 @@
+package notification
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/aaron-vaz/golang-utils/pkg/ioutils"
+)
+
+// DefaultSlackAPIURL is the url of the slack web api
+const DefaultSlackAPIURL = "https://slack.com/api"
+
+const (
+	lookupByEmailPath = "/users.lookupByEmail?email=%s"
+	postMessagePath   = "/chat.postMessage"
+)
+
+// Person is someone responsible for a branch, identified by their github login or team and the email of their commits when known
+type Person struct {
+	Login string
+	Email string
+}
+
+// lookupByEmailResponse is the struct that represents the slack users.lookupByEmail response
+type lookupByEmailResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+	User  struct {
+		ID string `json:"id"`
+	} `json:"user"`
+}
+
+// postMessageResponse is the struct that represents the slack chat.postMessage response
+type postMessageResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+	TS    string `json:"ts"`
+}
+
+// UserResolver finds the slack id of the people responsible for a branch
+// the override table is checked first using the github login and then the email, other emails are looked up with users.lookupByEmail
+type UserResolver struct {
+	BaseURL string
+	// Token is the slack bot token used for lookups, emails are not looked up when it is empty
+	Token string
+	// Overrides maps github logins, teams and emails to slack ids
+	Overrides map[string]string
+	*http.Client
+
+	mu sync.Mutex
+	// cache keeps the lookups, including emails without a slack user, so each email is only looked up once
+	cache map[string]string
+}
+
+// Resolve returns the slack id of the supplied person, it is empty when they have no slack user
+func (r *UserResolver) Resolve(person Person) string {
+	if r == nil {
+		return ""
+	}
+
+	if id, ok := r.Overrides[person.Login]; ok && person.Login != "" {
+		return id
+	}
+
+	if id, ok := r.Overrides[person.Email]; ok && person.Email != "" {
+		return id
+	}
+
+	if r.Token == "" || person.Email == "" {
+		return ""
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if id, ok := r.cache[person.Email]; ok {
+		return id
+	}
+
+	id, err := r.lookupByEmail(person.Email)
+	if err != nil {
+		// failed lookups are not cached so they are retried on the next branch
+		log.Printf("Could not look up the slack user of %s: %v", person.Email, err)
+		return ""
+	}
+
+	if r.cache == nil {
+		r.cache = make(map[string]string)
+	}
+	r.cache[person.Email] = id
+
+	return id
+}
+
+func (r *UserResolver) lookupByEmail(email string) (string, error) {
+	baseURL := r.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultSlackAPIURL
+	}
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(baseURL, "/")+fmt.Sprintf(lookupByEmailPath, url.QueryEscape(email)), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Add("Authorization", "Bearer "+r.Token)
+
+	res, err := r.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer ioutils.Close(res.Body)
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return "", fmt.Errorf("slack users.lookupByEmail failed with status %s", res.Status)
+	}
+
+	response := &lookupByEmailResponse{}
+	if err := json.Unmarshal(body, response); err != nil {
+		return "", err
+	}
+
+	switch {
+	case response.OK:
+		return response.User.ID, nil
+	case response.Error == "users_not_found":
+		return "", nil
+	default:
+		return "", fmt.Errorf("slack users.lookupByEmail failed: %s", response.Error)
+	}
+}
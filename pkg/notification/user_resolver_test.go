@@ -0,0 +1,146 @@
+package notification
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeSlackAPI is a local slack web api that knows the users in its directory
+type fakeSlackAPI struct {
+	*httptest.Server
+	mu      sync.Mutex
+	lookups map[string]int
+}
+
+func newFakeSlackAPI(directory map[string]string) *fakeSlackAPI {
+	f := &fakeSlackAPI{lookups: make(map[string]int)}
+
+	f.Server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/users.lookupByEmail" {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if req.Header.Get("Authorization") != "Bearer xoxb-token" {
+			rw.Write([]byte(`{"ok": false, "error": "invalid_auth"}`))
+			return
+		}
+
+		email := req.URL.Query().Get("email")
+		f.mu.Lock()
+		f.lookups[email]++
+		f.mu.Unlock()
+
+		if id, ok := directory[email]; ok {
+			rw.Write([]byte(`{"ok": true, "user": {"id": "` + id + `", "name": "octocat"}}`))
+			return
+		}
+
+		rw.Write([]byte(`{"ok": false, "error": "users_not_found"}`))
+	}))
+
+	return f
+}
+
+func TestUserResolver_Resolve(t *testing.T) {
+	server := newFakeSlackAPI(map[string]string{"octocat@example.com": "U123", "someone@example.com": "U456"})
+	defer server.Close()
+
+	resolver := &UserResolver{
+		BaseURL:   server.URL,
+		Token:     "xoxb-token",
+		Overrides: map[string]string{"someone": "U999", "org/platform": "S111"},
+		Client:    server.Client(),
+	}
+
+	tests := []struct {
+		name   string
+		person Person
+		want   string
+	}{
+		{
+			name:   "Test lookup by email path",
+			person: Person{Login: "octocat", Email: "octocat@example.com"},
+			want:   "U123",
+		},
+		{
+			name:   "Test cached lookup path",
+			person: Person{Email: "octocat@example.com"},
+			want:   "U123",
+		},
+		{
+			name:   "Test override by login path",
+			person: Person{Login: "someone", Email: "someone@example.com"},
+			want:   "U999",
+		},
+		{
+			name:   "Test override by team path",
+			person: Person{Login: "org/platform"},
+			want:   "S111",
+		},
+		{
+			name:   "Test user not found path",
+			person: Person{Login: "stranger", Email: "stranger@example.com"},
+		},
+		{
+			name:   "Test cached user not found path",
+			person: Person{Email: "stranger@example.com"},
+		},
+		{
+			name:   "Test login without email path",
+			person: Person{Login: "nobody"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolver.Resolve(tt.person); got != tt.want {
+				t.Errorf("UserResolver.Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if want := map[string]int{"octocat@example.com": 1, "stranger@example.com": 1}; !cmp.Equal(server.lookups, want) {
+		t.Errorf("Unexpected slack lookups, want = %v, got = %v", want, server.lookups)
+	}
+}
+
+func TestUserResolver_Resolve_Errors(t *testing.T) {
+	server := newFakeSlackAPI(map[string]string{"octocat@example.com": "U123"})
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		resolver *UserResolver
+	}{
+		{
+			name:     "Test no token path",
+			resolver: &UserResolver{BaseURL: server.URL, Client: server.Client()},
+		},
+		{
+			name:     "Test invalid token path",
+			resolver: &UserResolver{BaseURL: server.URL, Token: "xoxb-revoked", Client: server.Client()},
+		},
+		{
+			name:     "Test unknown endpoint path",
+			resolver: &UserResolver{BaseURL: server.URL + "/missing", Token: "xoxb-token", Client: server.Client()},
+		},
+		{
+			name: "Test nil resolver path",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.resolver.Resolve(Person{Login: "octocat", Email: "octocat@example.com"}); got != "" {
+				t.Errorf("UserResolver.Resolve() = %q, want no slack user", got)
+			}
+
+			if tt.resolver != nil && len(tt.resolver.cache) != 0 {
+				t.Errorf("UserResolver.Resolve() cached a failed lookup, got = %v", tt.resolver.cache)
+			}
+		})
+	}
+}
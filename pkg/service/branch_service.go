@@ -3,14 +3,18 @@ package service
 import (
 	"fmt"
 	"log"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/aaron-vaz/github-branch-bot/pkg/config"
 	"github.com/aaron-vaz/github-branch-bot/pkg/github"
+	"github.com/aaron-vaz/github-branch-bot/pkg/match"
 	"github.com/aaron-vaz/github-branch-bot/pkg/notification"
+	"github.com/aaron-vaz/github-branch-bot/pkg/state"
 )
 
-const projectUpToDateText = "up to date with %s\n"
+const ruleHeaderText = "_%s_"
 
 // BranchService is the main struct, it is used to start the application
 type BranchService struct {
@@ -18,56 +22,895 @@ type BranchService struct {
 	API    *github.APIService
 	Msg    *notification.SlackService
 	Wg     *sync.WaitGroup
+	// State keeps the branches that are ahead between runs, state is not kept when it is nil
+	State state.Store
+	// ReadOnly checks the branches without acting on them for other people, such as when a slack user runs the check
+	// the full report is always built and the state is loaded but never saved, so the next scheduled run still reports the changes
+	// escalations are not fired, so they still reach their channel on the next scheduled run
+	ReadOnly bool
+
+	// snapshot records the branches that are ahead in the current run
+	snapshot *state.Snapshot
+	// delta reports only the changes since the last run in the current run
+	delta bool
+
+	mu sync.Mutex
+	// reports are sent on top of the summaries of the current run, such as escalations posted to other channels
+	reports []notification.Report
+}
+
+// rule is a branch rule along with its compiled patterns
+type rule struct {
+	config.Rule
+	matcher *match.Matcher
+}
+
+// owner is an org or user account being checked along with the api and rules used for its repos
+type owner struct {
+	config.Owner
+	api   *github.APIService
+	rules []rule
+	// qualified prefixes the repo names in the report with the owner name, it is used when several owners share a report
+	qualified bool
+}
+
+// repository holds the details of the repo being processed
+type repository struct {
+	owner         *owner
+	name          string
+	url           string
+	defaultBranch string
+	// base is the branch selected branches are compared against unless their pattern overrides it
+	base string
+	// codeOwners are the CODEOWNERS rules of the repo, they are only loaded when codeowners are mentioned
+	codeOwners *github.CodeOwners
+}
+
+// reportName returns the name the repo is listed under in the report
+func (r repository) reportName() string {
+	if r.owner.qualified {
+		return r.owner.Name + "/" + r.name
+	}
+	return r.name
 }
 
 // GenerateStatusMessage is used to start the application
+// when a report is sent per owner the reports are joined together
 func (b *BranchService) GenerateStatusMessage() string {
-	sm := &notification.SlackMessage{
-		Org:      b.Params.GithubOrganization,
-		Messages: make(map[string][]string),
+	return strings.Join(b.GenerateStatusMessages(), "\n")
+}
+
+// GenerateStatusMessages checks the repos of every owner and returns the reports to send
+// a single report covering every owner is returned unless a report per owner is configured
+func (b *BranchService) GenerateStatusMessages() []string {
+	var messages []string
+	for _, report := range b.GenerateReports() {
+		messages = append(messages, report.Text)
+	}
+
+	return messages
+}
+
+// GenerateReports checks the repos of every owner and returns the reports to send along with their webhooks
+// the repos of owners with teams are grouped by team, teams with their own webhook get a report of their own
+func (b *BranchService) GenerateReports() (reports []notification.Report) {
+	b.startSnapshot()
+	defer b.saveSnapshot()
+
+	// escalations posted to their own webhooks are sent after the summaries
+	b.reports = nil
+	defer func() { reports = append(reports, b.reports...) }()
+
+	owners := b.Params.Owners()
+	perOwner := b.Params.ReportMode == config.ReportPerOwner
+
+	var names []string
+	for _, o := range owners {
+		names = append(names, o.Name)
 	}
 
-	repositories := b.API.GetRepositoriesInOrg(b.Params.GithubOrganization, b.Params.BaseBranch)
+	combined := &notification.SlackMessage{
+		Org:       strings.Join(names, ", "),
+		Messages:  make(map[string][]string),
+		Templates: b.Msg.Templates,
+	}
+
+	var teamMessages []*notification.SlackMessage
+
+	for _, o := range owners {
+		if len(o.Teams) > 0 {
+			messages := b.checkTeams(o, &reports)
+			if report, ok := b.newReport("", messages...); perOwner && ok {
+				reports = append(reports, report)
+			}
+
+			teamMessages = append(teamMessages, messages...)
+			continue
+		}
+
+		sm := combined
+		if perOwner {
+			sm = &notification.SlackMessage{Org: o.Name, Messages: make(map[string][]string), Templates: b.Msg.Templates}
+		}
+
+		b.checkOwner(o, "", !perOwner && len(owners) > 1, sm)
+
+		if report, ok := b.newReport("", sm); perOwner && ok {
+			reports = append(reports, report)
+		}
+	}
+
+	if perOwner {
+		return reports
+	}
+
+	if report, ok := b.newReport("", append([]*notification.SlackMessage{combined}, teamMessages...)...); ok {
+		reports = append(reports, report)
+	}
+
+	return reports
+}
+
+// newReport joins the summaries of the supplied messages into a report sent to the supplied webhook, ok is false when they are all empty
+// the repos that are up to date but left out of the summaries are added to the details when they are sent in a thread or attachment
+func (b *BranchService) newReport(webhookURL string, messages ...*notification.SlackMessage) (report notification.Report, ok bool) {
+	var sections, details []string
+	for _, sm := range messages {
+		if message := sm.String(); message != "" {
+			sections = append(sections, message)
+		}
+		if detail := sm.Details(); detail != "" {
+			details = append(details, detail)
+		}
+	}
+
+	if len(sections) == 0 {
+		return report, false
+	}
+
+	report = notification.Report{WebhookURL: webhookURL, Text: strings.Join(sections, "\n")}
+	if b.Params.UpToDateMode == config.UpToDateThread || b.Params.UpToDateMode == config.UpToDateAttachment {
+		report.Details = strings.Join(details, "\n")
+		// webhooks cannot start threads, so only the reports sent to the default channel are threaded
+		report.Thread = b.Params.UpToDateMode == config.UpToDateThread && webhookURL == ""
+	}
+
+	return report, true
+}
+
+// startSnapshot loads the state of the last run and starts the snapshot of this run
+// state is not kept for the run when it cannot be loaded, so the first seen times are not lost
+func (b *BranchService) startSnapshot() {
+	b.snapshot, b.delta = nil, false
+	if b.State == nil {
+		return
+	}
+
+	previous, err := b.State.Load()
+	if err != nil {
+		log.Printf("Could not load the state of the last run: %v", err)
+		return
+	}
+
+	now := time.Now()
+	b.snapshot = state.NewSnapshot(previous, now)
+
+	// the full report is still sent on the digest day, when there is no earlier run to compare against and on read only runs
+	digestDay, ok := b.Params.DigestWeekday()
+	b.delta = b.Params.NotifyMode == config.NotifyDelta && !b.ReadOnly && !previous.RunAt.IsZero() && !(ok && now.Weekday() == digestDay)
+}
+
+// saveSnapshot saves the snapshot of this run, keeping the branches of the repos that were not checked
+// read only runs leave the state of the last run in place
+func (b *BranchService) saveSnapshot() {
+	if b.snapshot == nil || b.ReadOnly {
+		return
+	}
+
+	b.snapshot.Complete()
+	if err := b.State.Save(b.snapshot); err != nil {
+		log.Printf("Could not save the state of this run: %v", err)
+	}
+}
+
+// checkTeams checks the repos of every team of the supplied owner
+// the reports of teams with their own webhook are added to the reports, the summaries of the other teams are returned
+func (b *BranchService) checkTeams(o config.Owner, reports *[]notification.Report) []*notification.SlackMessage {
+	var messages []*notification.SlackMessage
+	for _, team := range o.Teams {
+		sm := &notification.SlackMessage{Org: o.Name, Team: team.Slug, Messages: make(map[string][]string), Templates: b.Msg.Templates}
+		b.checkOwner(o, team.Slug, false, sm)
+
+		if team.WebhookURL == "" {
+			messages = append(messages, sm)
+			continue
+		}
+
+		if report, ok := b.newReport(team.WebhookURL, sm); ok {
+			*reports = append(*reports, report)
+		}
+	}
+
+	return messages
+}
+
+// checkOwner adds the messages for the repos of the supplied owner to the slack message
+// when a team is supplied only the repos of that team are checked
+func (b *BranchService) checkOwner(configured config.Owner, team string, qualified bool, sm *notification.SlackMessage) {
+	rules, err := b.compileRules(b.Params.RulesFor(configured))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	o := &owner{
+		Owner:     configured,
+		api:       b.API.ForOwner(configured.Name, configured.IsUser(), configured.Token),
+		rules:     rules,
+		qualified: qualified,
+	}
+
+	// in repo mode every repo is checked against its own base branch
+	// listed repos are always checked, whatever their default branch
+	defaultBranch := b.Params.BaseBranch
+	if b.Params.BaseBranchMode == config.BaseModeRepo {
+		defaultBranch = ""
+	}
+
+	if len(o.Repositories) > 0 {
+		b.checkListedRepositories(o, sm)
+		return
+	}
+
+	var repositories []github.Response
+	switch {
+	case team != "":
+		repositories = o.api.GetTeamRepositories(o.Name, team, defaultBranch)
+	case o.IsUser():
+		repositories = o.api.GetRepositoriesOfUser(o.Name, defaultBranch)
+	default:
+		repositories = o.api.GetRepositoriesInOrg(o.Name, defaultBranch)
+	}
 
 	if len(repositories) == 0 {
-		log.Printf("No branches in %s contain a default branch %s", b.Params.GithubOrganization, b.Params.BaseBranch)
-		return ""
+		log.Printf("No branches in %s contain a default branch %s", o.Name, defaultBranch)
+		return
 	}
 
+	repositories = b.filterRepositories(o.Name, repositories)
+
 	b.Wg.Add(len(repositories))
 
 	for _, repo := range repositories {
-		go b.processRepo(repo, sm)
+		go b.processRepo(o, repo, sm)
 	}
 
 	b.Wg.Wait()
+}
+
+// checkListedRepositories checks the repos listed for the supplied owner, repos that cannot be found are left out
+// listed owners may be orgs or users, so each repo is requested with the app installation found through the repo itself
+func (b *BranchService) checkListedRepositories(o *owner, sm *notification.SlackMessage) {
+	for _, name := range o.Repositories {
+		listed := *o
+		listed.api = b.API.ForRepository(o.Name, name, o.Token)
+
+		repo := listed.api.GetRepository(o.Name, name)
+		if repo.Name == "" {
+			log.Printf("Could not find repo %s/%s, check configuration", o.Name, name)
+			continue
+		}
+
+		b.Wg.Add(1)
+		go b.processRepo(&listed, *repo, sm)
+	}
+
+	b.Wg.Wait()
+}
+
+// filterRepositories returns the repos that match the repo filter, disabled repos are always left out
+func (b *BranchService) filterRepositories(owner string, repositories []github.Response) []github.Response {
+	filter := b.Params.RepoFilter
+
+	names, err := filter.Matcher()
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+
+	var filtered []github.Response
+	for _, repo := range repositories {
+		if reason := filterReason(filter, names, repo); reason != "" {
+			log.Printf("Skipping %s repo %s, %s", owner, repo.Name, reason)
+			continue
+		}
+
+		filtered = append(filtered, repo)
+	}
+
+	return filtered
+}
+
+// filterReason returns why the supplied repo is left out by the filter, it is empty when the repo is checked
+func filterReason(filter config.RepoFilter, names *match.Matcher, repo github.Response) string {
+	switch {
+	case repo.Disabled:
+		return "it is disabled"
+
+	case repo.Archived && !filter.IncludeArchived:
+		return "it is archived"
+
+	case repo.Fork && filter.SkipForks:
+		return "it is a fork"
+
+	case repo.IsTemplate && filter.SkipTemplates:
+		return "it is a template"
+
+	case len(filter.Topics) > 0 && !containsAny(repo.Topics, filter.Topics):
+		return fmt.Sprintf("it has none of the topics %s", filter.Topics)
+
+	case len(filter.Visibility) > 0 && !containsAny([]string{repo.Visibility}, filter.Visibility):
+		return fmt.Sprintf("its visibility %s is not one of %s", repo.Visibility, filter.Visibility)
+
+	case !names.Match(repo.Name):
+		return "its name does not match the repo filter"
+
+	case filter.PushedWithinDays > 0 && time.Since(repo.PushedAt) > time.Duration(filter.PushedWithinDays)*24*time.Hour:
+		return fmt.Sprintf("it has not been pushed to in %d days", filter.PushedWithinDays)
+	}
+
+	return ""
+}
 
-	return sm.String()
+// compileRules compiles the patterns of the supplied branch rules
+func (b *BranchService) compileRules(configured []config.Rule) ([]rule, error) {
+	var rules []rule
+	for _, r := range configured {
+		matcher, err := r.Matcher()
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, rule{Rule: r, matcher: matcher})
+	}
+
+	return rules, nil
 }
 
-func (b *BranchService) processRepo(repo string, sm *notification.SlackMessage) {
+func (b *BranchService) processRepo(o *owner, response github.Response, sm *notification.SlackMessage) {
 	defer b.Wg.Done()
 
-	branches := b.API.GetBranches(b.Params.GithubOrganization, repo, b.Params.HeadBranchPrefixes)
+	// a repo whose branches could not be listed must not pass for a repo that is up to date,
+	// so it is left out of the run along with its state, issues and statuses
+	allBranches, err := o.api.GetBranches(o.Name, response.Name, nil)
+	if err != nil {
+		log.Printf("Could not list the branches of %s, leaving repo out of the report: %v", response.Name, err)
+		return
+	}
+
+	repo := b.newRepository(o, response, allBranches)
+	if b.Params.MentionsFrom(config.MentionCodeOwners) {
+		repo.codeOwners = o.api.GetCodeOwners(o.Name, repo.name)
+	}
+
+	var branches []string
+	var bases []string
+	var branchMessages []string
+	var handledBranches []string
+	var failedBranches []string
+	var aheadBranches []notification.BranchStatus
+
+	// rules are evaluated in order so the messages of each repo are grouped by rule
+	for _, r := range o.rules {
+		selections := r.matcher.Select(allBranches)
+		for _, selection := range selections {
+			branches = appendUnique(branches, selection.Name)
+		}
+
+		for _, base := range b.baseBranches(repo, r, selections) {
+			bases = appendUnique(bases, base)
+		}
+
+		messages, handled, ahead, failed := b.checkAheadBranches(repo, r, selections)
+		if len(messages) > 0 && r.Name != "" {
+			branchMessages = append(branchMessages, fmt.Sprintf(ruleHeaderText, r.Name))
+		}
+
+		branchMessages = append(branchMessages, messages...)
+		handledBranches = append(handledBranches, handled...)
+		aheadBranches = append(aheadBranches, ahead...)
+		failedBranches = append(failedBranches, failed...)
+	}
+
+	// a failed comparison must not pass for a branch that is up to date, so when one fails the state of the repo is kept
+	// and the issues, statuses and resolved messages describing the whole repo are left until the next run
+	complete := len(failedBranches) == 0
+	if complete && b.snapshot != nil {
+		b.snapshot.Check(o.Name, repo.name)
+	}
+
+	if b.Params.DriftIssues && complete {
+		issues := &notification.GitHubIssueNotifier{API: o.api, Label: b.Params.DriftIssueLabel}
+		if err := issues.Notify(o.Name, repo.name, aheadBranches); err != nil {
+			log.Printf("Could not update the branch drift issue of %s: %v", repo.name, err)
+		}
+	}
 
 	if len(branches) == 0 {
-		log.Printf("No branches of %s matched prefixes %s, check configuration", repo, b.Params.HeadBranchPrefixes)
-		return
+		var heads []string
+		for _, r := range o.rules {
+			heads = append(heads, r.Head...)
+		}
+
+		log.Printf("No branches of %s matched prefixes %s, check configuration", repo.name, heads)
+	}
+
+	if b.delta && complete {
+		branchMessages = append(branchMessages, b.resolvedMessages(repo)...)
+	}
+
+	branchMessages = append(branchMessages, b.checkStaleBranches(repo, branches, allBranches)...)
+
+	// the base is only shown in the report when it can differ between repos
+	var reportBase string
+	if b.Params.BaseBranchMode == config.BaseModeRepo {
+		reportBase = repo.base
+	}
+
+	if len(bases) == 0 {
+		bases = append(bases, repo.base)
+	}
+
+	if b.Params.CommitStatus && complete {
+		b.setCommitStatuses(repo, bases, aheadBranches)
+	}
+
+	if len(branchMessages) > 0 {
+		sm.AddMessages(repo.reportName(), reportBase, repo.url, branchMessages)
+
+	} else if !complete {
+		log.Printf("Branches %s of %s could not be compared, leaving repo out of the report", failedBranches, repo.name)
+
+	} else if len(handledBranches) > 0 {
+		log.Printf("Branches %s of %s have open pull requests or are below the minimum severity, leaving repo out of the report", handledBranches, repo.name)
+
+	} else if len(branches) > 0 && !b.delta {
+		message := b.Msg.GenerateUpToDateMessage(repo.reportName(), bases)
+		if b.Params.ListsUpToDate() {
+			sm.AddMessages(repo.reportName(), reportBase, repo.url, []string{message})
+		} else {
+			sm.AddUpToDate(repo.reportName(), reportBase, repo.url, message)
+		}
+	}
+}
+
+// setCommitStatuses sets the commit status of every base branch of the repo from the branches that are ahead of it
+func (b *BranchService) setCommitStatuses(repo repository, bases []string, ahead []notification.BranchStatus) {
+	statuses := &notification.CommitStatusNotifier{API: repo.owner.api, Context: b.Params.CommitStatusContext, Threshold: b.Params.CommitStatusThreshold}
+
+	for _, base := range bases {
+		var branches []notification.BranchStatus
+		for _, status := range ahead {
+			if status.Base == base {
+				branches = append(branches, status)
+			}
+		}
+
+		if err := statuses.Notify(repo.owner.Name, repo.name, base, branches); err != nil {
+			log.Printf("Could not set the commit status of %s %s: %v", repo.name, base, err)
+		}
+	}
+}
+
+// newRepository works out the base branch of the supplied repo
+// in repo mode the first base branch candidate found in the listed branches of the repo is used, otherwise its default branch
+func (b *BranchService) newRepository(o *owner, response github.Response, branches []string) repository {
+	repo := repository{owner: o, name: response.Name, url: response.HTMLURL, defaultBranch: response.DefaultBranch, base: b.Params.BaseBranch}
+
+	if b.Params.BaseBranchMode != config.BaseModeRepo {
+		return repo
 	}
 
-	aheadBranches := b.API.GetAheadBy(b.Params.GithubOrganization, repo, b.Params.BaseBranch, branches)
+	repo.base = response.DefaultBranch
+	for _, candidate := range b.Params.BaseBranchCandidates {
+		if containsAny(branches, []string{candidate}) {
+			repo.base = candidate
+			break
+		}
+	}
+
+	log.Printf("Using %s as the base branch of %s", repo.base, repo.name)
+	return repo
+}
+
+// checkAheadBranches returns the messages for the selected branches that are ahead of their base branch by at least the rule threshold
+// along with the branches that were left out because they already have an open pull request or are below the minimum severity,
+// the status of every branch that is ahead and the branches that could not be compared
+func (b *BranchService) checkAheadBranches(repo repository, r rule, selections []match.Selection) ([]string, []string, []notification.BranchStatus, []string) {
+	if len(selections) == 0 {
+		return nil, nil, nil, nil
+	}
+
+	var failedBranches []string
+	comparisons := make(map[string]*github.CompareBranches)
+	for _, selection := range selections {
+		if base := b.baseBranch(repo, r, selection); base != selection.Name {
+			log.Printf("Checking %s branch %s", repo.name, selection.Name)
+
+			comparison, err := repo.owner.api.GetComparison(repo.owner.Name, repo.name, base, selection.Name)
+			if err != nil {
+				log.Printf("Could not compare %s branch %s with %s: %v", repo.name, selection.Name, base, err)
+				failedBranches = append(failedBranches, selection.Name)
+				continue
+			}
+			comparisons[selection.Name] = comparison
+		}
+	}
 
 	var branchMessages []string
-	for branch, aheadBy := range aheadBranches {
-		if message := b.Msg.GenerateMessage(repo, b.Params.BaseBranch, branch, aheadBy); message != "" {
+	var handledBranches []string
+	var aheadBranches []notification.BranchStatus
+	for _, selection := range selections {
+		branch, base := selection.Name, b.baseBranch(repo, r, selection)
+		comparison, ok := comparisons[branch]
+		if !ok {
+			continue
+		}
+
+		status := notification.BranchStatus{
+			Owner:      repo.owner.Name,
+			Repo:       repo.name,
+			Rule:       r.Name,
+			Base:       base,
+			Head:       branch,
+			Ahead:      comparison.Ahead,
+			Behind:     comparison.Behind,
+			Commits:    comparison.Commits,
+			CodeOwners: codeOwnersOf(repo, comparison),
+			CompareURL: comparison.HTMLURL,
+		}
+		if repo.url != "" {
+			status.URL = repo.url + "/tree/" + branch
+		}
+
+		wasAhead := false
+		if comparison.Ahead > 0 {
+			wasAhead = b.recordState(repo, &status, comparison)
+		}
+
+		if comparison.Ahead < r.MinAhead() {
+			continue
+		}
+
+		escalated := b.escalate(repo, &status, comparison)
+
+		pulls, err := repo.owner.api.GetOpenPullRequests(repo.owner.Name, repo.name, base, branch)
+		if err != nil {
+			log.Printf("Could not look up the open pull requests of %s branch %s: %v", repo.name, branch, err)
+		} else if len(pulls) > 0 {
+			status.PullRequest = &pulls[0]
+		}
+
+		thresholds := b.Params.Severity.ThresholdsFor(r.Rule, repo.owner.Name, repo.name)
+		status.Severity = thresholds.Level(status.Ahead, status.DaysAhead)
+		if !thresholds.IsZero() {
+			status.Emoji = b.Params.Severity.EmojiFor(status.Severity)
+		}
+
+		// every branch that is ahead is kept for the drift issues, even when it is left out of the report
+		aheadBranches = append(aheadBranches, status)
+
+		if !b.Params.Severity.Shown(status.Severity) {
+			handledBranches = append(handledBranches, branch)
+			continue
+		}
+
+		// in delta mode branches that were already ahead are only reported when they have grown enough or have been escalated
+		if b.delta && wasAhead && status.Change < b.Params.DeltaThreshold && !escalated {
+			continue
+		}
+
+		if b.Params.IgnoreOpenPullRequests && status.PullRequest != nil {
+			handledBranches = append(handledBranches, branch)
+			continue
+		}
+
+		if len(b.Params.Mentions) > 0 && status.Mentions == "" {
+			status.Mentions = b.Msg.FormatMentions(b.responsiblePeople(repo, comparison, b.Params.Mentions))
+		}
+
+		if message := b.Msg.GenerateMessage(status, r.Template); message != "" {
 			branchMessages = append(branchMessages, message)
 		}
 	}
 
-	if len(branchMessages) > 0 {
-		sm.Messages[repo] = branchMessages
+	return branchMessages, handledBranches, aheadBranches, failedBranches
+}
+
+// recordState records the supplied branch that is ahead in the snapshot of the run, the age and change since the last run are added to its status
+// it reports whether the branch was already ahead in the last run
+func (b *BranchService) recordState(repo repository, status *notification.BranchStatus, comparison *github.CompareBranches) bool {
+	if b.snapshot == nil {
+		return false
+	}
+
+	branch := state.BranchState{
+		Owner:  repo.owner.Name,
+		Repo:   repo.name,
+		Branch: status.Head,
+		Base:   status.Base,
+		Ahead:  comparison.Ahead,
+		Behind: comparison.Behind,
+	}
+
+	previous, ok := b.snapshot.Record(branch)
+	if ok {
+		status.Change = comparison.Ahead - previous.Ahead
+	}
+
+	recorded, _ := b.snapshot.Get(branch.Key())
+	status.DaysAhead = recorded.DaysAhead()
+	return ok
+}
 
-	} else {
-		sm.Messages[repo] = []string{fmt.Sprintf(projectUpToDateText, b.Params.BaseBranch)}
+// escalate fires the escalations the supplied branch has reached since the last run, each level fires once
+// a level whose issue could not be opened is not recorded, so it and the levels after it are retried on the next run
+// it reports whether an escalation fired so the branch is reported even when it has not changed
+func (b *BranchService) escalate(repo repository, status *notification.BranchStatus, comparison *github.CompareBranches) bool {
+	if b.snapshot == nil || b.ReadOnly || len(b.Params.Escalations) == 0 {
+		return false
+	}
+
+	key := state.Key(repo.owner.Name, repo.name, status.Head, status.Base)
+	recorded, ok := b.snapshot.Get(key)
+	level := b.Params.EscalationLevel(status.DaysAhead)
+	if !ok || level <= recorded.EscalationLevel {
+		return false
+	}
+
+	reached := recorded.EscalationLevel
+	for i := recorded.EscalationLevel; i < level; i++ {
+		escalation := b.Params.Escalations[i]
+
+		if escalation.Mention && status.Mentions == "" {
+			sources := b.Params.Mentions
+			if len(sources) == 0 {
+				sources = []string{config.MentionAuthors}
+			}
+			status.Mentions = b.Msg.FormatMentions(b.responsiblePeople(repo, comparison, sources))
+		}
+
+		if escalation.Issue {
+			issue, err := repo.owner.api.CreateIssue(repo.owner.Name, repo.name, b.Msg.GenerateEscalationIssue(*status, escalation.Labels))
+			if err != nil {
+				log.Printf("Could not open an escalation issue for %s branch %s, retrying on the next run: %v", repo.name, status.Head, err)
+				break
+			}
+			log.Printf("Opened escalation issue %s for %s branch %s", issue.HTMLURL, repo.name, status.Head)
+		}
+
+		if escalation.WebhookURL != "" {
+			b.addReport(notification.Report{WebhookURL: escalation.WebhookURL, Text: b.Msg.GenerateEscalationMessage(repo.owner.Name, *status, i+1)})
+		}
+
+		reached = i + 1
+	}
+
+	if reached == recorded.EscalationLevel {
+		return false
+	}
+
+	b.snapshot.Escalate(key, reached)
+	return true
+}
+
+// addReport adds a report that is sent on top of the summaries, it is safe to call from multiple goroutines
+func (b *BranchService) addReport(report notification.Report) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.reports = append(b.reports, report)
+}
+
+// resolvedMessages returns the messages for the branches of the supplied repo that were ahead in the last run and are now up to date
+func (b *BranchService) resolvedMessages(repo repository) []string {
+	var messages []string
+	for _, branch := range b.snapshot.Resolved(repo.owner.Name, repo.name) {
+		messages = append(messages, b.Msg.GenerateResolvedMessage(repo.name, branch.Branch, branch.Base))
+	}
+
+	return messages
+}
+
+// codeOwnersOf returns the CODEOWNERS of the files changed by the compared branch, in the order they are first found
+func codeOwnersOf(repo repository, comparison *github.CompareBranches) []string {
+	var owners []string
+	for _, file := range comparison.Files {
+		for _, owner := range repo.codeOwners.Owners(file.Filename) {
+			owners = appendUnique(owners, owner)
+		}
+	}
+	return owners
+}
+
+// responsiblePeople returns the people responsible for the commits the supplied branch is ahead by
+// they are the CODEOWNERS of the changed files and the authors of the commits, depending on the supplied mention sources
+func (b *BranchService) responsiblePeople(repo repository, comparison *github.CompareBranches, sources []string) []notification.Person {
+	// people are the same when they share a login or an email, the details known about them are merged
+	var people []notification.Person
+	add := func(person notification.Person) {
+		for i, p := range people {
+			if (p.Login != "" && p.Login == person.Login) || (p.Email != "" && p.Email == person.Email) {
+				if people[i].Login == "" {
+					people[i].Login = person.Login
+				}
+				if people[i].Email == "" {
+					people[i].Email = person.Email
+				}
+				return
+			}
+		}
+		people = append(people, person)
+	}
+
+	if containsAny(sources, []string{config.MentionCodeOwners}) {
+		for _, owner := range codeOwnersOf(repo, comparison) {
+			// CODEOWNERS lists users and teams as @login and @org/team, anything else is an email
+			if strings.HasPrefix(owner, "@") {
+				add(notification.Person{Login: strings.TrimPrefix(owner, "@")})
+			} else {
+				add(notification.Person{Email: owner})
+			}
+		}
+	}
+
+	if containsAny(sources, []string{config.MentionAuthors}) {
+		for _, commit := range comparison.Commits {
+			if person := (notification.Person{Login: commit.Author.Login, Email: commit.Commit.Author.Email}); person != (notification.Person{}) {
+				add(person)
+			}
+		}
+	}
+
+	return people
+}
+
+// baseBranch returns the base branch the selected branch is compared against
+func (b *BranchService) baseBranch(repo repository, r rule, selection match.Selection) string {
+	return r.BaseBranchFor(selection.Pattern.String(), repo.base)
+}
+
+// baseBranches returns the distinct base branches of the selected branches in the order they were selected
+func (b *BranchService) baseBranches(repo repository, r rule, selections []match.Selection) []string {
+	var bases []string
+	for _, selection := range selections {
+		bases = appendUnique(bases, b.baseBranch(repo, r, selection))
+	}
+
+	return bases
+}
+
+// checkStaleBranches returns the messages for the branches that have had no commits for the configured number of days
+// only the matched branches are checked unless every branch of the repo should be
+func (b *BranchService) checkStaleBranches(repo repository, branches, allBranches []string) []string {
+	if b.Params.StaleBranchDays <= 0 {
+		return nil
+	}
+
+	if b.Params.StaleBranchesAll {
+		branches = allBranches
+	}
+
+	var staleMessages []string
+	for _, name := range branches {
+		if b.isBaseBranch(repo, name) {
+			continue
+		}
+
+		branch, err := repo.owner.api.GetBranch(repo.owner.Name, repo.name, name)
+		if err != nil || branch.LastActivity().IsZero() {
+			log.Printf("Could not find the last commit of %s branch %s: %v", repo.name, name, err)
+			continue
+		}
+
+		if days := int(time.Since(branch.LastActivity()).Hours() / 24); days >= b.Params.StaleBranchDays {
+			staleMessages = append(staleMessages, b.Msg.GenerateStaleMessage(repo.name, branch, days))
+
+			if message := b.cleanupStaleBranch(repo, branch); message != "" {
+				staleMessages = append(staleMessages, message)
+			}
+		}
+	}
+
+	return staleMessages
+}
+
+// cleanupStaleBranch deletes the supplied stale branch when cleanup is enabled
+// protected branches, the base and default branches and branches with open pull requests are never deleted
+// nor are branches whose pull requests could not be looked up
+func (b *BranchService) cleanupStaleBranch(repo repository, branch *github.Branch) string {
+	mode := b.Params.StaleBranchCleanup
+	if mode != config.CleanupDryRun && mode != config.CleanupDelete {
+		return ""
+	}
+
+	if branch.Protected || b.isBaseBranch(repo, branch.Name) {
+		log.Printf("Not deleting %s branch %s, it is protected or a base branch", repo.name, branch.Name)
+		return ""
+	}
+
+	open, err := b.hasOpenPullRequests(repo, branch.Name)
+	if err != nil {
+		log.Printf("Not deleting %s branch %s, could not look up its open pull requests: %v", repo.name, branch.Name, err)
+		return ""
+	}
+
+	if open {
+		log.Printf("Not deleting %s branch %s, it has open pull requests", repo.name, branch.Name)
+		return ""
+	}
+
+	if mode == config.CleanupDryRun {
+		return b.Msg.GenerateDeletionMessage(repo.name, branch, true, nil)
+	}
+
+	err = repo.owner.api.DeleteBranch(repo.owner.Name, repo.name, branch.Name)
+	return b.Msg.GenerateDeletionMessage(repo.name, branch, false, err)
+}
+
+// hasOpenPullRequests reports whether the supplied branch is the head or the base of an open pull request
+// deleting the branch would close the pull requests into it as well as those from it
+func (b *BranchService) hasOpenPullRequests(repo repository, branch string) (bool, error) {
+	from, err := repo.owner.api.GetOpenPullRequests(repo.owner.Name, repo.name, "", branch)
+	if err != nil || len(from) > 0 {
+		return len(from) > 0, err
+	}
+
+	into, err := repo.owner.api.GetOpenPullRequests(repo.owner.Name, repo.name, branch, "")
+	if err != nil {
+		return false, err
+	}
+
+	return len(into) > 0, nil
+}
+
+// isBaseBranch reports whether the supplied branch is the default branch, the base branch or one of the base branch overrides of the repo
+func (b *BranchService) isBaseBranch(repo repository, name string) bool {
+	if name == b.Params.BaseBranch || name == repo.base || name == repo.defaultBranch {
+		return true
+	}
+
+	for _, r := range repo.owner.rules {
+		if name == r.Base {
+			return true
+		}
+
+		for _, base := range r.BaseOverrides {
+			if name == base {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func containsAny(values, wanted []string) bool {
+	for _, value := range values {
+		for _, w := range wanted {
+			if value == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
 	}
+	return append(values, value)
 }
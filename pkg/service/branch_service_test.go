@@ -1,6 +1,8 @@
 package service
 
 import (
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -8,10 +10,13 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/aaron-vaz/github-branch-bot/pkg/config"
 	"github.com/aaron-vaz/github-branch-bot/pkg/github"
 	"github.com/aaron-vaz/github-branch-bot/pkg/notification"
+	"github.com/aaron-vaz/github-branch-bot/pkg/state"
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestBranchService_GenerateStatusMessage(t *testing.T) {
@@ -19,7 +24,28 @@ func TestBranchService_GenerateStatusMessage(t *testing.T) {
 		name             string
 		reposResponse    []byte
 		branchesResponse []byte
+		branchResponse   []byte
 		compareResponse  []byte
+		pullsResponse    []byte
+		basePulls        []byte
+		pullsStatus      int
+		ignoreOpenPulls  bool
+		staleBranchDays  int
+		staleAll         bool
+		cleanup          string
+		wantDeleted      bool
+		prefixes         []string
+		overrides        map[string]string
+		baseMode         string
+		candidates       []string
+		rules            []config.Rule
+		mentions         []string
+		slackUsers       map[string]string
+		slackLookup      bool
+		codeOwners       []byte
+		severity         config.Severity
+		templates        config.MessageTemplates
+		upToDateMode     string
 		messageDelivered bool
 		messageWant      string
 	}{
@@ -31,6 +57,36 @@ func TestBranchService_GenerateStatusMessage(t *testing.T) {
 			messageDelivered: true,
 			messageWant:      "*org branch check summary:*\n\n*test*:\nmaster is ahead of develop by 1 commits\n\n",
 		},
+		{
+			name:             "Test codeowners mentions path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			compareResponse:  readTestResource("ahead-happy-path.json"),
+			codeOwners:       readTestResource("codeowners-happy-path.json"),
+			mentions:         []string{config.MentionCodeOwners, config.MentionAuthors},
+			slackUsers:       map[string]string{"octocat": "U123"},
+			messageDelivered: true,
+			messageWant:      "*org branch check summary:*\n\n*test*:\nmaster is ahead of develop by 1 commits cc <@U123> docs@example.com\n\n",
+		},
+		{
+			name:             "Test authors mentions without codeowners path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			compareResponse:  readTestResource("ahead-happy-path.json"),
+			mentions:         []string{config.MentionAuthors},
+			messageDelivered: true,
+			messageWant:      "*org branch check summary:*\n\n*test*:\nmaster is ahead of develop by 1 commits cc @octocat\n\n",
+		},
+		{
+			name:             "Test authors resolved by email path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			compareResponse:  readTestResource("ahead-happy-path.json"),
+			mentions:         []string{config.MentionAuthors},
+			slackLookup:      true,
+			messageDelivered: true,
+			messageWant:      "*org branch check summary:*\n\n*test*:\nmaster is ahead of develop by 1 commits cc <@U321>\n\n",
+		},
 		{
 			name:             "Test Branches inline path",
 			reposResponse:    readTestResource("repos-happy-path.json"),
@@ -39,6 +95,274 @@ func TestBranchService_GenerateStatusMessage(t *testing.T) {
 			messageDelivered: true,
 			messageWant:      "*org branch check summary:*\n\n*test*:\nup to date with develop\n\n",
 		},
+		{
+			name:             "Test open pull request path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			compareResponse:  readTestResource("ahead-happy-path.json"),
+			pullsResponse:    readTestResource("pulls-happy-path.json"),
+			messageDelivered: true,
+			messageWant:      fmt.Sprintf("*org branch check summary:*\n\n*test*:\nmaster is ahead of develop by 1 commits, PR #1347 open for %d days by @octocat\n\n", daysSince("2011-01-26T19:01:12Z")),
+		},
+		{
+			name:             "Test ignore open pull request path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			compareResponse:  readTestResource("ahead-happy-path.json"),
+			pullsResponse:    readTestResource("pulls-happy-path.json"),
+			ignoreOpenPulls:  true,
+			messageDelivered: false,
+			messageWant:      "",
+		},
+		{
+			name:             "Test stale branch path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			branchResponse:   readTestResource("branch-happy-path.json"),
+			compareResponse:  readTestResource("inline-happy-path.json"),
+			staleBranchDays:  30,
+			messageDelivered: true,
+			messageWant:      fmt.Sprintf("*org branch check summary:*\n\n*test*:\nmaster has had no activity for %d days, last commit 7fd1a60 by octocat\n\n", daysSince("2012-03-06T23:06:50Z")),
+		},
+		{
+			name:             "Test stale branch under threshold path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			branchResponse:   readTestResource("branch-happy-path.json"),
+			compareResponse:  readTestResource("inline-happy-path.json"),
+			staleBranchDays:  daysSince("2012-03-06T23:06:50Z") + 1,
+			messageDelivered: true,
+			messageWant:      "*org branch check summary:*\n\n*test*:\nup to date with develop\n\n",
+		},
+		{
+			name:             "Test stale branch dry run path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			branchResponse:   readTestResource("branch-unprotected.json"),
+			compareResponse:  readTestResource("inline-happy-path.json"),
+			staleBranchDays:  30,
+			cleanup:          config.CleanupDryRun,
+			messageDelivered: true,
+			messageWant:      fmt.Sprintf("*org branch check summary:*\n\n*test*:\nmaster has had no activity for %d days, last commit 7fd1a60 by octocat\n\nwould delete stale branch master at 7fd1a60b01f91b314f59955a4e4d4e80d8edf11d\n\n", daysSince("2012-03-06T23:06:50Z")),
+		},
+		{
+			name:             "Test stale branch delete path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			branchResponse:   readTestResource("branch-unprotected.json"),
+			compareResponse:  readTestResource("inline-happy-path.json"),
+			staleBranchDays:  30,
+			cleanup:          config.CleanupDelete,
+			wantDeleted:      true,
+			messageDelivered: true,
+			messageWant:      fmt.Sprintf("*org branch check summary:*\n\n*test*:\nmaster has had no activity for %d days, last commit 7fd1a60 by octocat\n\ndeleted stale branch master at 7fd1a60b01f91b314f59955a4e4d4e80d8edf11d\n\n", daysSince("2012-03-06T23:06:50Z")),
+		},
+		{
+			name:             "Test stale protected branch path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			branchResponse:   readTestResource("branch-happy-path.json"),
+			compareResponse:  readTestResource("inline-happy-path.json"),
+			staleBranchDays:  30,
+			cleanup:          config.CleanupDelete,
+			messageDelivered: true,
+			messageWant:      fmt.Sprintf("*org branch check summary:*\n\n*test*:\nmaster has had no activity for %d days, last commit 7fd1a60 by octocat\n\n", daysSince("2012-03-06T23:06:50Z")),
+		},
+		{
+			name:             "Test stale branches of the whole repo path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			branchResponse:   readTestResource("branch-happy-path.json"),
+			compareResponse:  readTestResource("inline-happy-path.json"),
+			staleBranchDays:  30,
+			staleAll:         true,
+			messageDelivered: true,
+			messageWant: fmt.Sprintf("*org branch check summary:*\n\n*test*:\nmaster has had no activity for %d days, last commit 7fd1a60 by octocat\n\nmaster has had no activity for %[1]d days, last commit 7fd1a60 by octocat\n\n",
+				daysSince("2012-03-06T23:06:50Z")),
+		},
+		{
+			name:             "Test stale branch with open pull request path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			branchResponse:   readTestResource("branch-unprotected.json"),
+			compareResponse:  readTestResource("inline-happy-path.json"),
+			pullsResponse:    readTestResource("pulls-happy-path.json"),
+			staleBranchDays:  30,
+			cleanup:          config.CleanupDelete,
+			messageDelivered: true,
+			messageWant:      fmt.Sprintf("*org branch check summary:*\n\n*test*:\nmaster has had no activity for %d days, last commit 7fd1a60 by octocat\n\n", daysSince("2012-03-06T23:06:50Z")),
+		},
+		{
+			name:             "Test stale branch with pull request into it path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			branchResponse:   readTestResource("branch-unprotected.json"),
+			compareResponse:  readTestResource("inline-happy-path.json"),
+			basePulls:        readTestResource("pulls-happy-path.json"),
+			staleBranchDays:  30,
+			cleanup:          config.CleanupDelete,
+			messageDelivered: true,
+			messageWant:      fmt.Sprintf("*org branch check summary:*\n\n*test*:\nmaster has had no activity for %d days, last commit 7fd1a60 by octocat\n\n", daysSince("2012-03-06T23:06:50Z")),
+		},
+		{
+			name:             "Test stale branch pull request lookup failure path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			branchResponse:   readTestResource("branch-unprotected.json"),
+			compareResponse:  readTestResource("inline-happy-path.json"),
+			pullsStatus:      http.StatusForbidden,
+			staleBranchDays:  30,
+			cleanup:          config.CleanupDelete,
+			messageDelivered: true,
+			messageWant:      fmt.Sprintf("*org branch check summary:*\n\n*test*:\nmaster has had no activity for %d days, last commit 7fd1a60 by octocat\n\n", daysSince("2012-03-06T23:06:50Z")),
+		},
+		{
+			name:             "Test overlapping prefixes path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			compareResponse:  readTestResource("ahead-happy-path.json"),
+			prefixes:         []string{"master", "mas", ""},
+			messageDelivered: true,
+			messageWant:      "*org branch check summary:*\n\n*test*:\nmaster is ahead of develop by 1 commits\n\nrelease is ahead of develop by 1 commits\n\n",
+		},
+		{
+			name:             "Test base branch override path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			compareResponse:  readTestResource("ahead-happy-path.json"),
+			prefixes:         []string{"master", "release"},
+			overrides:        map[string]string{"release": "main"},
+			messageDelivered: true,
+			messageWant:      "*org branch check summary:*\n\n*test*:\nmaster is ahead of develop by 1 commits\n\nrelease is ahead of main by 1 commits\n\n",
+		},
+		{
+			name:             "Test base branch override up to date path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			compareResponse:  readTestResource("inline-happy-path.json"),
+			prefixes:         []string{"master", "release"},
+			overrides:        map[string]string{"release": "main"},
+			messageDelivered: true,
+			messageWant:      "*org branch check summary:*\n\n*test*:\nup to date with develop, main\n\n",
+		},
+		{
+			name:             "Test repo base branch mode path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			compareResponse:  readTestResource("ahead-happy-path.json"),
+			baseMode:         config.BaseModeRepo,
+			messageDelivered: true,
+			messageWant:      "*org branch check summary:*\n\n*test* (base develop):\nmaster is ahead of develop by 1 commits\n\n",
+		},
+		{
+			name:             "Test repo base branch candidate path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			compareResponse:  readTestResource("ahead-happy-path.json"),
+			prefixes:         []string{"master", "release"},
+			baseMode:         config.BaseModeRepo,
+			candidates:       []string{"main", "master"},
+			messageDelivered: true,
+			messageWant:      "*org branch check summary:*\n\n*test* (base master):\nrelease is ahead of master by 1 commits\n\n",
+		},
+		{
+			name:             "Test branch rules path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			compareResponse:  readTestResource("ahead-happy-path.json"),
+			rules: []config.Rule{
+				{Name: "release", Head: []string{"release"}, Template: "{{.Head}} needs merging into {{.Base}}"},
+				{Name: "master", Base: "main", Head: []string{"master"}},
+			},
+			messageDelivered: true,
+			messageWant:      "*org branch check summary:*\n\n*test*:\n_release_\nrelease needs merging into develop\n\n_master_\nmaster is ahead of main by 1 commits\n\n",
+		},
+		{
+			name:             "Test branch rules threshold path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			compareResponse:  readTestResource("ahead-happy-path.json"),
+			rules: []config.Rule{
+				{Name: "release", Head: []string{"release"}, Threshold: 2},
+				{Name: "master", Base: "main", Head: []string{"master"}},
+			},
+			messageDelivered: true,
+			messageWant:      "*org branch check summary:*\n\n*test*:\n_master_\nmaster is ahead of main by 1 commits\n\n",
+		},
+		{
+			name:             "Test branch rules under threshold path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			compareResponse:  readTestResource("ahead-happy-path.json"),
+			rules: []config.Rule{
+				{Name: "release", Head: []string{"release"}, Threshold: 2},
+				{Name: "master", Base: "main", Head: []string{"master"}, Threshold: 5},
+			},
+			messageDelivered: true,
+			messageWant:      "*org branch check summary:*\n\n*test*:\nup to date with develop, main\n\n",
+		},
+		{
+			name:             "Test severity emoji path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			compareResponse:  readTestResource("ahead-happy-path.json"),
+			severity:         config.Severity{SeverityThresholds: config.SeverityThresholds{Warning: config.Threshold{Ahead: 1}, Critical: config.Threshold{Ahead: 10}}},
+			messageDelivered: true,
+			messageWant:      "*org branch check summary:*\n\n*test*:\n:warning: master is ahead of develop by 1 commits\n\n",
+		},
+		{
+			name:             "Test severity rule thresholds path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			compareResponse:  readTestResource("ahead-happy-path.json"),
+			rules: []config.Rule{
+				{Name: "master", Head: []string{"master"}, Severity: &config.SeverityThresholds{Critical: config.Threshold{Ahead: 1}}},
+			},
+			severity:         config.Severity{Emoji: map[string]string{config.SeverityCritical: ":fire:"}},
+			messageDelivered: true,
+			messageWant:      "*org branch check summary:*\n\n*test*:\n_master_\n:fire: master is ahead of develop by 1 commits\n\n",
+		},
+		{
+			name:             "Test severity below minimum path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			compareResponse:  readTestResource("ahead-happy-path.json"),
+			severity:         config.Severity{SeverityThresholds: config.SeverityThresholds{Warning: config.Threshold{Ahead: 5}}, Minimum: config.SeverityWarning},
+			messageDelivered: false,
+			messageWant:      "",
+		},
+		{
+			name:             "Test templates path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			compareResponse:  readTestResource("ahead-happy-path.json"),
+			templates: config.MessageTemplates{
+				Summary: "*Branch drift in {{.Org}}*",
+				Repo:    "<{{.URL}}|{{.Repo}}>",
+				Branch:  "<{{.URL}}|{{.Head}}> is {{.Ahead}} ahead and {{.Behind}} behind {{.Base}} by {{range .Commits}}{{.AuthorName}}{{end}}, <{{.CompareURL}}|compare>",
+			},
+			messageDelivered: true,
+			messageWant: "*Branch drift in org*\n\n<https://github.com/octocat/Hello-World|test>\n" +
+				"<https://github.com/octocat/Hello-World/tree/master|master> is 1 ahead and 2 behind develop by octocat, <https://github.com/octocat/Hello-World/compare/master...topic|compare>\n\n",
+		},
+		{
+			name:             "Test up to date template path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			compareResponse:  readTestResource("inline-happy-path.json"),
+			templates:        config.MessageTemplates{UpToDate: "{{.Repo}} is all caught up with {{.Base}}"},
+			messageDelivered: true,
+			messageWant:      "*org branch check summary:*\n\n*test*:\ntest is all caught up with develop\n\n",
+		},
+		{
+			name:             "Test omit up to date path",
+			reposResponse:    readTestResource("repos-happy-path.json"),
+			branchesResponse: readTestResource("branches-happy-path.json"),
+			compareResponse:  readTestResource("inline-happy-path.json"),
+			upToDateMode:     config.UpToDateOmit,
+			messageDelivered: true,
+			messageWant:      "*org branch check summary:*\n\n1 repos up to date\n",
+		},
 		{
 			name:             "Test No matched repos",
 			reposResponse:    readTestResource("invalid.json"),
@@ -67,28 +391,85 @@ func TestBranchService_GenerateStatusMessage(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			deleted := false
+			listed := 0
 			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
-				if strings.Contains(req.RequestURI, "orgs") {
+				if req.Method == http.MethodDelete {
+					deleted = true
+					rw.WriteHeader(http.StatusNoContent)
+
+				} else if req.URL.Path == "/users.lookupByEmail" && req.URL.Query().Get("email") == "support@github.com" {
+					rw.Write([]byte(`{"ok": true, "user": {"id": "U321"}}`))
+
+				} else if strings.Contains(req.RequestURI, "orgs") {
 					rw.Write(tt.reposResponse)
 
+				} else if strings.Contains(req.RequestURI, "branches/") {
+					rw.Write(tt.branchResponse)
+
 				} else if strings.Contains(req.RequestURI, "branches") {
+					listed++
 					rw.Write(tt.branchesResponse)
 
 				} else if strings.Contains(req.RequestURI, "compare") {
 					rw.Write(tt.compareResponse)
+
+				} else if strings.Contains(req.RequestURI, "pulls") && tt.pullsStatus != 0 {
+					rw.WriteHeader(tt.pullsStatus)
+					rw.Write([]byte(`{"message": "API rate limit exceeded"}`))
+
+				} else if strings.Contains(req.RequestURI, "pulls") && req.URL.Query().Get("head") == "" && tt.basePulls != nil {
+					rw.Write(tt.basePulls)
+
+				} else if strings.Contains(req.RequestURI, "pulls") && req.URL.Query().Get("head") != "" && tt.pullsResponse != nil {
+					rw.Write(tt.pullsResponse)
+
+				} else if strings.Contains(req.RequestURI, "pulls") {
+					rw.Write([]byte("[]"))
+
+				} else if strings.Contains(req.RequestURI, "contents") && tt.codeOwners != nil {
+					rw.Write(tt.codeOwners)
+
+				} else if strings.Contains(req.RequestURI, "contents") {
+					rw.WriteHeader(http.StatusNotFound)
 				}
 			}))
 
+			prefixes := tt.prefixes
+			if prefixes == nil {
+				prefixes = []string{"master"}
+			}
+
 			params := &config.Params{
-				GithubToken:        "token",
-				GithubOrganization: "org",
-				BaseBranch:         "develop",
-				HeadBranchPrefixes: []string{"master"},
-				WebhookURL:         server.URL,
+				GithubToken:          "token",
+				GithubOrganization:   "org",
+				BaseBranch:           "develop",
+				HeadBranchPrefixes:   prefixes,
+				WebhookURL:           server.URL,
+				BaseOverrides:        tt.overrides,
+				BaseBranchMode:       tt.baseMode,
+				BaseBranchCandidates: tt.candidates,
+				BranchRules:          tt.rules,
+				Mentions:             tt.mentions,
+				Severity:             tt.severity,
+				UpToDateMode:         tt.upToDateMode,
+
+				IgnoreOpenPullRequests: tt.ignoreOpenPulls,
+				StaleBranchDays:        tt.staleBranchDays,
+				StaleBranchesAll:       tt.staleAll,
+				StaleBranchCleanup:     tt.cleanup,
 			}
 
 			githubAPI := &github.APIService{BaseURL: server.URL, Client: server.Client()}
-			slackAPI := &notification.SlackService{Client: server.Client()}
+			users := &notification.UserResolver{Overrides: tt.slackUsers, Client: server.Client()}
+			if tt.slackLookup {
+				users.BaseURL, users.Token = server.URL, "xoxb-token"
+			}
+			templates, err := notification.NewTemplates(tt.templates.Summary, tt.templates.Repo, tt.templates.Branch, tt.templates.UpToDate)
+			if err != nil {
+				t.Fatal(err)
+			}
+			slackAPI := &notification.SlackService{Client: server.Client(), Users: users, Templates: templates}
 
 			bot := &BranchService{
 				Params: params,
@@ -102,6 +483,801 @@ func TestBranchService_GenerateStatusMessage(t *testing.T) {
 			if actualMessage != tt.messageWant {
 				t.Errorf("Unexpected test result for GenerateStatusMessage want = %s, got = %s", tt.messageWant, actualMessage)
 			}
+
+			if deleted != tt.wantDeleted {
+				t.Errorf("Unexpected branch deletion, want = %t, got = %t", tt.wantDeleted, deleted)
+			}
+
+			if listed > 1 {
+				t.Errorf("Branches of the repo listed %d times, want at most once", listed)
+			}
+		})
+	}
+}
+
+func TestBranchService_GenerateStatusMessages(t *testing.T) {
+	owners := []config.Owner{
+		{Name: "org-one"},
+		{Name: "someone", Type: config.OwnerUser, Token: "user-token", Rules: []config.Rule{{Head: []string{"release"}}}},
+	}
+
+	tests := []struct {
+		name         string
+		reportMode   string
+		repositories []string
+		wantListed   bool
+		want         []string
+	}{
+		{
+			name:       "Test combined report path",
+			wantListed: true,
+			want: []string{"*org-one, someone branch check summary:*\n\n" +
+				"*org-one/test*:\nmaster is ahead of develop by 1 commits\n\n" +
+				"*someone/test*:\nrelease is ahead of develop by 1 commits\n\n"},
+		},
+		{
+			name:         "Test repositories path",
+			repositories: []string{"someone/test", "org-one/test"},
+			want: []string{"*someone, org-one branch check summary:*\n\n" +
+				"*org-one/test*:\nmaster is ahead of develop by 1 commits\n\n" +
+				"*someone/test*:\nrelease is ahead of develop by 1 commits\n\n"},
+		},
+		{
+			name:       "Test report per owner path",
+			reportMode: config.ReportPerOwner,
+			wantListed: true,
+			want: []string{
+				"*org-one branch check summary:*\n\n*test*:\nmaster is ahead of develop by 1 commits\n\n",
+				"*someone branch check summary:*\n\n*test*:\nrelease is ahead of develop by 1 commits\n\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			tokens := make(map[string]string)
+			listed := false
+
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				owner := strings.Split(req.URL.Path, "/")[2]
+				mu.Lock()
+				tokens[owner] = req.Header.Get("Authorization")
+				listed = listed || strings.HasSuffix(req.URL.Path, "/repos")
+				mu.Unlock()
+
+				if strings.HasSuffix(req.URL.Path, "/repos") {
+					rw.Write(readTestResource("repos-happy-path.json"))
+
+				} else if strings.Count(req.URL.Path, "/") == 3 {
+					rw.Write(readTestResource("repo-happy-path.json"))
+
+				} else if strings.Contains(req.URL.Path, "branches") {
+					rw.Write(readTestResource("branches-happy-path.json"))
+
+				} else if strings.Contains(req.URL.Path, "compare") {
+					rw.Write(readTestResource("ahead-happy-path.json"))
+				}
+			}))
+			defer server.Close()
+
+			params := &config.Params{
+				BaseBranch:         "develop",
+				HeadBranchPrefixes: []string{"master"},
+				BaseBranchMode:     config.BaseModeFixed,
+				GithubOwners:       owners,
+				ReportMode:         tt.reportMode,
+				Repositories:       tt.repositories,
+			}
+
+			bot := &BranchService{
+				Params: params,
+				API:    &github.APIService{BaseURL: server.URL, Token: "token", Client: server.Client()},
+				Msg:    &notification.SlackService{Client: server.Client()},
+				Wg:     &sync.WaitGroup{},
+			}
+
+			got := bot.GenerateStatusMessages()
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Unexpected test result for GenerateStatusMessages want = %q, got = %q", tt.want, got)
+			}
+
+			if tokens["org-one"] != "token token" || tokens["someone"] != "token user-token" {
+				t.Errorf("Unexpected authorization for owners, got = %v", tokens)
+			}
+
+			if listed != tt.wantListed {
+				t.Errorf("Unexpected repo listing, want = %t, got = %t", tt.wantListed, listed)
+			}
+		})
+	}
+}
+
+func TestBranchService_GenerateReports_Teams(t *testing.T) {
+	var mu sync.Mutex
+	var teams []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if strings.HasSuffix(req.URL.Path, "/repos") {
+			mu.Lock()
+			teams = append(teams, req.URL.Path)
+			mu.Unlock()
+			rw.Write(readTestResource("repos-happy-path.json"))
+
+		} else if strings.Contains(req.URL.Path, "branches") {
+			rw.Write(readTestResource("branches-happy-path.json"))
+
+		} else if strings.Contains(req.URL.Path, "compare") {
+			rw.Write(readTestResource("ahead-happy-path.json"))
+		}
+	}))
+	defer server.Close()
+
+	params := &config.Params{
+		BaseBranch:         "develop",
+		HeadBranchPrefixes: []string{"master"},
+		BaseBranchMode:     config.BaseModeFixed,
+		GithubOrganization: "org-one",
+		Teams:              []config.Team{{Slug: "payments", WebhookURL: "https://hooks.slack.com/payments"}, {Slug: "platform"}},
+	}
+
+	bot := &BranchService{
+		Params: params,
+		API:    &github.APIService{BaseURL: server.URL, Token: "token", Client: server.Client()},
+		Msg:    &notification.SlackService{Client: server.Client()},
+		Wg:     &sync.WaitGroup{},
+	}
+
+	want := []notification.Report{
+		{
+			WebhookURL: "https://hooks.slack.com/payments",
+			Text:       "*org-one team payments branch check summary:*\n\n*test*:\nmaster is ahead of develop by 1 commits\n\n",
+		},
+		{
+			Text: "*org-one team platform branch check summary:*\n\n*test*:\nmaster is ahead of develop by 1 commits\n\n",
+		},
+	}
+
+	if got := bot.GenerateReports(); !cmp.Equal(got, want) {
+		t.Errorf("Unexpected test result for GenerateReports, diff = %s", cmp.Diff(want, got))
+	}
+
+	if wantTeams := []string{"/orgs/org-one/teams/payments/repos", "/orgs/org-one/teams/platform/repos"}; !cmp.Equal(teams, wantTeams) {
+		t.Errorf("Unexpected repo listing, want = %v, got = %v", wantTeams, teams)
+	}
+}
+
+func TestBranchService_GenerateReports_RepositoriesWithTeams(t *testing.T) {
+	var mu sync.Mutex
+	compared := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if strings.Contains(req.URL.Path, "compare") {
+			mu.Lock()
+			compared++
+			mu.Unlock()
+			rw.Write(readTestResource("ahead-happy-path.json"))
+
+		} else if strings.Contains(req.URL.Path, "branches") {
+			rw.Write(readTestResource("branches-happy-path.json"))
+
+		} else {
+			rw.Write(readTestResource("repo-happy-path.json"))
+		}
+	}))
+	defer server.Close()
+
+	params := &config.Params{
+		BaseBranch:         "develop",
+		HeadBranchPrefixes: []string{"master"},
+		BaseBranchMode:     config.BaseModeFixed,
+		GithubOwners:       []config.Owner{{Name: "org", Teams: []config.Team{{Slug: "a"}, {Slug: "b"}}}},
+		Repositories:       []string{"org/test"},
+	}
+
+	bot := &BranchService{
+		Params: params,
+		API:    &github.APIService{BaseURL: server.URL, Token: "token", Client: server.Client()},
+		Msg:    &notification.SlackService{Client: server.Client()},
+		Wg:     &sync.WaitGroup{},
+	}
+
+	// the listed repo is checked once rather than once for each team of its owner
+	want := []notification.Report{{Text: "*org branch check summary:*\n\n*test*:\nmaster is ahead of develop by 1 commits\n\n"}}
+	if got := bot.GenerateReports(); !cmp.Equal(got, want) {
+		t.Errorf("Unexpected test result for GenerateReports, diff = %s", cmp.Diff(want, got))
+	}
+
+	if compared != 1 {
+		t.Errorf("Unexpected number of comparisons, want = 1, got = %d", compared)
+	}
+}
+
+func TestBranchService_GenerateReports_UpToDateThread(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if strings.HasSuffix(req.URL.Path, "/repos") {
+			rw.Write(readTestResource("repos-happy-path.json"))
+
+		} else if strings.Contains(req.URL.Path, "branches") {
+			rw.Write(readTestResource("branches-happy-path.json"))
+
+		} else if strings.Contains(req.URL.Path, "compare") {
+			rw.Write(readTestResource("inline-happy-path.json"))
+		}
+	}))
+	defer server.Close()
+
+	params := &config.Params{
+		BaseBranch:         "develop",
+		HeadBranchPrefixes: []string{"master"},
+		BaseBranchMode:     config.BaseModeFixed,
+		GithubOrganization: "org-one",
+		Teams:              []config.Team{{Slug: "payments", WebhookURL: "https://hooks.slack.com/payments"}, {Slug: "platform"}},
+		UpToDateMode:       config.UpToDateThread,
+	}
+
+	bot := &BranchService{
+		Params: params,
+		API:    &github.APIService{BaseURL: server.URL, Token: "token", Client: server.Client()},
+		Msg:    &notification.SlackService{Client: server.Client()},
+		Wg:     &sync.WaitGroup{},
+	}
+
+	// the team with its own webhook cannot be threaded, so its details are sent as an attachment
+	want := []notification.Report{
+		{
+			WebhookURL: "https://hooks.slack.com/payments",
+			Text:       "*org-one team payments branch check summary:*\n\n1 repos up to date\n",
+			Details:    "*test*:\nup to date with develop\n\n",
+		},
+		{
+			Text:    "*org-one team platform branch check summary:*\n\n1 repos up to date\n",
+			Details: "*test*:\nup to date with develop\n\n",
+			Thread:  true,
+		},
+	}
+
+	if got := bot.GenerateReports(); !cmp.Equal(got, want) {
+		t.Errorf("Unexpected test result for GenerateReports, diff = %s", cmp.Diff(want, got))
+	}
+}
+
+func TestBranchService_GenerateStatusMessage_State(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if strings.HasSuffix(req.URL.Path, "/repos") {
+			rw.Write(readTestResource("repos-happy-path.json"))
+
+		} else if strings.Contains(req.URL.Path, "branches") {
+			rw.Write(readTestResource("branches-happy-path.json"))
+
+		} else if strings.Contains(req.URL.Path, "compare") {
+			rw.Write(readTestResource("ahead-happy-path.json"))
+		}
+	}))
+	defer server.Close()
+
+	firstSeen := time.Now().Add(-3 * 24 * time.Hour)
+
+	store := &state.MemoryStore{}
+	previous := state.NewSnapshot(nil, firstSeen)
+	previous.Record(state.BranchState{Owner: "org", Repo: "test", Branch: "master", Base: "develop", Ahead: 3})
+	previous.Record(state.BranchState{Owner: "org", Repo: "gone", Branch: "master", Base: "develop", Ahead: 1})
+	if err := store.Save(previous); err != nil {
+		t.Fatal(err)
+	}
+
+	bot := &BranchService{
+		Params: &config.Params{
+			GithubOrganization: "org",
+			BaseBranch:         "develop",
+			HeadBranchPrefixes: []string{"master"},
+			BaseBranchMode:     config.BaseModeFixed,
+		},
+		API:   &github.APIService{BaseURL: server.URL, Token: "token", Client: server.Client()},
+		Msg:   &notification.SlackService{Client: server.Client()},
+		Wg:    &sync.WaitGroup{},
+		State: store,
+	}
+
+	want := "*org branch check summary:*\n\n*test*:\nmaster is ahead of develop by 1 commits (-2 since the last run), ahead for 3 days\n\n"
+	if got := bot.GenerateStatusMessage(); got != want {
+		t.Errorf("Unexpected test result for GenerateStatusMessage want = %q, got = %q", want, got)
+	}
+
+	saved, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	branch := saved.Branches[state.Key("org", "test", "master", "develop")]
+	if branch.Ahead != 1 || branch.Behind != 2 || !branch.FirstSeen.Equal(firstSeen) {
+		t.Errorf("Unexpected saved state, got = %+v", branch)
+	}
+
+	// the repo that was not checked in this run is kept
+	if _, ok := saved.Branches[state.Key("org", "gone", "master", "develop")]; !ok {
+		t.Errorf("Unexpected saved state, branches of repos that were not checked were dropped, got = %v", saved.Branches)
+	}
+}
+
+func TestBranchService_GenerateReports_CompareFailure(t *testing.T) {
+	var mu sync.Mutex
+	var changes []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			mu.Lock()
+			changes = append(changes, req.Method+" "+req.URL.Path)
+			mu.Unlock()
+		}
+
+		if strings.HasSuffix(req.URL.Path, "/repos") {
+			rw.Write(readTestResource("repos-happy-path.json"))
+
+		} else if strings.Contains(req.URL.Path, "branches/") {
+			rw.Write([]byte(`{"name": "develop", "commit": {"sha": "6dcb09b"}}`))
+
+		} else if strings.Contains(req.URL.Path, "branches") {
+			rw.Write(readTestResource("branches-happy-path.json"))
+
+		} else if strings.Contains(req.URL.Path, "compare") {
+			rw.WriteHeader(http.StatusForbidden)
+			rw.Write([]byte(`{"message": "API rate limit exceeded"}`))
+
+		} else {
+			rw.Write([]byte("[]"))
+		}
+	}))
+	defer server.Close()
+
+	firstSeen := time.Now().Add(-5 * 24 * time.Hour)
+
+	store := &state.MemoryStore{}
+	previous := state.NewSnapshot(nil, firstSeen)
+	previous.Record(state.BranchState{Owner: "org", Repo: "test", Branch: "master", Base: "develop", Ahead: 3})
+	previous.Escalate(state.Key("org", "test", "master", "develop"), 1)
+	if err := store.Save(previous); err != nil {
+		t.Fatal(err)
+	}
+
+	bot := &BranchService{
+		Params: &config.Params{
+			GithubOrganization: "org",
+			BaseBranch:         "develop",
+			HeadBranchPrefixes: []string{"master"},
+			BaseBranchMode:     config.BaseModeFixed,
+			NotifyMode:         config.NotifyDelta,
+			DeltaThreshold:     1,
+			DriftIssues:        true,
+			CommitStatus:       true,
+		},
+		API:   &github.APIService{BaseURL: server.URL, Token: "token", Client: server.Client()},
+		Msg:   &notification.SlackService{Client: server.Client()},
+		Wg:    &sync.WaitGroup{},
+		State: store,
+	}
+
+	// the branch is not reported as up to date and the drift issue and commit status are left alone
+	if got := bot.GenerateReports(); len(got) != 0 {
+		t.Errorf("Unexpected reports when the comparison failed %+v", got)
+	}
+
+	if len(changes) != 0 {
+		t.Errorf("Unexpected github changes when the comparison failed %v", changes)
+	}
+
+	saved, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	branch := saved.Branches[state.Key("org", "test", "master", "develop")]
+	if !branch.FirstSeen.Equal(firstSeen) || branch.EscalationLevel != 1 {
+		t.Errorf("Unexpected saved state, the state of the last run was not kept, got = %+v", branch)
+	}
+}
+
+func TestBranchService_GenerateReports_BranchesFailure(t *testing.T) {
+	var mu sync.Mutex
+	var changes []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			mu.Lock()
+			changes = append(changes, req.Method+" "+req.URL.Path)
+			mu.Unlock()
+		}
+
+		if strings.HasSuffix(req.URL.Path, "/repos") {
+			rw.Write(readTestResource("repos-happy-path.json"))
+
+		} else if strings.Contains(req.URL.Path, "branches/") {
+			rw.Write([]byte(`{"name": "develop", "commit": {"sha": "6dcb09b"}}`))
+
+		} else if strings.Contains(req.URL.Path, "branches") {
+			rw.WriteHeader(http.StatusForbidden)
+			rw.Write([]byte(`{"message": "API rate limit exceeded"}`))
+
+		} else if strings.Contains(req.URL.Path, "issues") {
+			rw.Write([]byte(`[{"number": 7, "title": "Branch drift", "state": "open"}]`))
+
+		} else {
+			rw.Write([]byte("[]"))
+		}
+	}))
+	defer server.Close()
+
+	firstSeen := time.Now().Add(-5 * 24 * time.Hour)
+
+	store := &state.MemoryStore{}
+	previous := state.NewSnapshot(nil, firstSeen)
+	previous.Record(state.BranchState{Owner: "org", Repo: "test", Branch: "master", Base: "develop", Ahead: 3})
+	previous.Escalate(state.Key("org", "test", "master", "develop"), 1)
+	if err := store.Save(previous); err != nil {
+		t.Fatal(err)
+	}
+
+	bot := &BranchService{
+		Params: &config.Params{
+			GithubOrganization: "org",
+			BaseBranch:         "develop",
+			HeadBranchPrefixes: []string{"master"},
+			BaseBranchMode:     config.BaseModeFixed,
+			NotifyMode:         config.NotifyDelta,
+			DeltaThreshold:     1,
+			DriftIssues:        true,
+			CommitStatus:       true,
+		},
+		API:   &github.APIService{BaseURL: server.URL, Token: "token", Client: server.Client()},
+		Msg:   &notification.SlackService{Client: server.Client()},
+		Wg:    &sync.WaitGroup{},
+		State: store,
+	}
+
+	// the branch is not reported as up to date and the drift issue and commit status are left alone
+	if got := bot.GenerateReports(); len(got) != 0 {
+		t.Errorf("Unexpected reports when the branches could not be listed %+v", got)
+	}
+
+	if len(changes) != 0 {
+		t.Errorf("Unexpected github changes when the branches could not be listed %v", changes)
+	}
+
+	saved, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	branch := saved.Branches[state.Key("org", "test", "master", "develop")]
+	if !branch.FirstSeen.Equal(firstSeen) || branch.EscalationLevel != 1 {
+		t.Errorf("Unexpected saved state, the state of the last run was not kept, got = %+v", branch)
+	}
+}
+
+func TestBranchService_GenerateStatusMessage_Delta(t *testing.T) {
+	lastRun := time.Now().Add(-24 * time.Hour)
+	today := time.Now().Weekday().String()
+
+	tests := []struct {
+		name      string
+		previous  []state.BranchState
+		compare   string
+		threshold int
+		digestDay string
+		readOnly  bool
+		want      string
+	}{
+		{
+			name:     "Test newly ahead path",
+			previous: []state.BranchState{{Owner: "org", Repo: "other", Branch: "master", Base: "develop", Ahead: 1}},
+			compare:  `{"ahead_by": 1}`,
+			want:     "*org branch check summary:*\n\n*test*:\nmaster is ahead of develop by 1 commits\n\n",
+		},
+		{
+			name:     "Test unchanged path",
+			previous: []state.BranchState{{Owner: "org", Repo: "test", Branch: "master", Base: "develop", Ahead: 1}},
+			compare:  `{"ahead_by": 1}`,
+		},
+		{
+			name:      "Test grown past threshold path",
+			previous:  []state.BranchState{{Owner: "org", Repo: "test", Branch: "master", Base: "develop", Ahead: 2}},
+			compare:   `{"ahead_by": 5}`,
+			threshold: 3,
+			want:      "*org branch check summary:*\n\n*test*:\nmaster is ahead of develop by 5 commits (+3 since the last run), ahead for 1 days\n\n",
+		},
+		{
+			name:      "Test grown below threshold path",
+			previous:  []state.BranchState{{Owner: "org", Repo: "test", Branch: "master", Base: "develop", Ahead: 2}},
+			compare:   `{"ahead_by": 4}`,
+			threshold: 3,
+		},
+		{
+			name:     "Test resolved path",
+			previous: []state.BranchState{{Owner: "org", Repo: "test", Branch: "master", Base: "develop", Ahead: 2}},
+			compare:  `{"ahead_by": 0}`,
+			want:     "*org branch check summary:*\n\n*test*:\nmaster is now up to date with develop\n\n",
+		},
+		{
+			name:      "Test weekly digest path",
+			previous:  []state.BranchState{{Owner: "org", Repo: "test", Branch: "master", Base: "develop", Ahead: 1}},
+			compare:   `{"ahead_by": 1}`,
+			digestDay: today,
+			want:      "*org branch check summary:*\n\n*test*:\nmaster is ahead of develop by 1 commits, ahead for 1 days\n\n",
+		},
+		{
+			name:     "Test read only path",
+			previous: []state.BranchState{{Owner: "org", Repo: "test", Branch: "master", Base: "develop", Ahead: 1}},
+			compare:  `{"ahead_by": 1}`,
+			readOnly: true,
+			want:     "*org branch check summary:*\n\n*test*:\nmaster is ahead of develop by 1 commits, ahead for 1 days\n\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				if strings.HasSuffix(req.URL.Path, "/repos") {
+					rw.Write(readTestResource("repos-happy-path.json"))
+
+				} else if strings.Contains(req.URL.Path, "branches") {
+					rw.Write(readTestResource("branches-happy-path.json"))
+
+				} else if strings.Contains(req.URL.Path, "compare") {
+					rw.Write([]byte(tt.compare))
+
+				} else if strings.Contains(req.URL.Path, "pulls") {
+					rw.Write([]byte("[]"))
+				}
+			}))
+			defer server.Close()
+
+			store := &state.MemoryStore{}
+			previous := state.NewSnapshot(nil, lastRun)
+			for _, branch := range tt.previous {
+				previous.Record(branch)
+			}
+			if err := store.Save(previous); err != nil {
+				t.Fatal(err)
+			}
+
+			threshold, digestDay := tt.threshold, tt.digestDay
+			if threshold == 0 {
+				threshold = 1
+			}
+			if digestDay == "" {
+				digestDay = "none"
+			}
+
+			bot := &BranchService{
+				Params: &config.Params{
+					GithubOrganization: "org",
+					BaseBranch:         "develop",
+					HeadBranchPrefixes: []string{"master"},
+					BaseBranchMode:     config.BaseModeFixed,
+					NotifyMode:         config.NotifyDelta,
+					DeltaThreshold:     threshold,
+					DigestDay:          digestDay,
+				},
+				API:      &github.APIService{BaseURL: server.URL, Token: "token", Client: server.Client()},
+				Msg:      &notification.SlackService{Client: server.Client()},
+				Wg:       &sync.WaitGroup{},
+				State:    store,
+				ReadOnly: tt.readOnly,
+			}
+
+			if got := bot.GenerateStatusMessage(); got != tt.want {
+				t.Errorf("Unexpected test result for GenerateStatusMessage want = %q, got = %q", tt.want, got)
+			}
+
+			// read only runs leave the state of the last run in place, so the next run compares against it
+			if saved, _ := store.Load(); tt.readOnly && !saved.RunAt.Equal(lastRun) {
+				t.Errorf("Unexpected state saved by a read only run, run at = %s, want = %s", saved.RunAt, lastRun)
+			}
+		})
+	}
+}
+
+func TestBranchService_GenerateReports_Escalations(t *testing.T) {
+	escalations := []config.Escalation{
+		{Days: 3, Mention: true},
+		{Days: 7, WebhookURL: "https://hooks.slack.com/services/leads"},
+		{Days: 14, Issue: true, Labels: []string{"branch-drift"}},
+	}
+
+	tests := []struct {
+		name      string
+		daysAhead int
+		readOnly  bool
+		failIssue bool
+		want      []notification.Report
+		issues    int
+		retried   int
+	}{
+		{
+			name:      "Test below first level path",
+			daysAhead: 1,
+			want: []notification.Report{
+				{Text: "*org branch check summary:*\n\n*test*:\nmaster is ahead of develop by 1 commits, ahead for 1 days\n\n"},
+			},
+		},
+		{
+			name:      "Test mention and webhook path",
+			daysAhead: 10,
+			want: []notification.Report{
+				{Text: "*org branch check summary:*\n\n*test*:\nmaster is ahead of develop by 1 commits, ahead for 10 days cc @octocat\n\n"},
+				{WebhookURL: "https://hooks.slack.com/services/leads", Text: "*org/test*: master has been ahead of develop by 1 commits for 10 days (escalation level 2) cc @octocat\n"},
+			},
+		},
+		{
+			name:      "Test issue path",
+			daysAhead: 20,
+			want: []notification.Report{
+				{Text: "*org branch check summary:*\n\n*test*:\nmaster is ahead of develop by 1 commits, ahead for 20 days cc @octocat\n\n"},
+				{WebhookURL: "https://hooks.slack.com/services/leads", Text: "*org/test*: master has been ahead of develop by 1 commits for 20 days (escalation level 2) cc @octocat\n"},
+			},
+			issues: 1,
+		},
+		{
+			name:      "Test failed issue path",
+			daysAhead: 20,
+			failIssue: true,
+			want: []notification.Report{
+				{Text: "*org branch check summary:*\n\n*test*:\nmaster is ahead of develop by 1 commits, ahead for 20 days cc @octocat\n\n"},
+				{WebhookURL: "https://hooks.slack.com/services/leads", Text: "*org/test*: master has been ahead of develop by 1 commits for 20 days (escalation level 2) cc @octocat\n"},
+			},
+			retried: 1,
+		},
+		{
+			name:      "Test read only path",
+			daysAhead: 20,
+			readOnly:  true,
+			want: []notification.Report{
+				{Text: "*org branch check summary:*\n\n*test*:\nmaster is ahead of develop by 1 commits, ahead for 20 days\n\n"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var issues []github.Issue
+			failIssue := tt.failIssue
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				if strings.HasSuffix(req.URL.Path, "/repos") {
+					rw.Write(readTestResource("repos-happy-path.json"))
+
+				} else if strings.Contains(req.URL.Path, "branches") {
+					rw.Write(readTestResource("branches-happy-path.json"))
+
+				} else if strings.Contains(req.URL.Path, "compare") {
+					rw.Write([]byte(`{"ahead_by": 1, "commits": [{"author": {"login": "octocat"}}]}`))
+
+				} else if strings.Contains(req.URL.Path, "pulls") {
+					rw.Write([]byte("[]"))
+
+				} else if strings.HasSuffix(req.URL.Path, "/issues") && req.Method == http.MethodPost && failIssue {
+					failIssue = false
+					rw.WriteHeader(http.StatusBadGateway)
+
+				} else if strings.HasSuffix(req.URL.Path, "/issues") && req.Method == http.MethodPost {
+					issue := github.Issue{}
+					if err := json.NewDecoder(req.Body).Decode(&issue); err != nil {
+						t.Fatal(err)
+					}
+					issues = append(issues, issue)
+					rw.Write([]byte(`{"number": 1, "html_url": "https://github.com/org/test/issues/1"}`))
+				}
+			}))
+			defer server.Close()
+
+			store := &state.MemoryStore{}
+			previous := state.NewSnapshot(nil, time.Now().Add(-time.Duration(tt.daysAhead)*24*time.Hour))
+			previous.Record(state.BranchState{Owner: "org", Repo: "test", Branch: "master", Base: "develop", Ahead: 1})
+			if err := store.Save(previous); err != nil {
+				t.Fatal(err)
+			}
+
+			bot := &BranchService{
+				Params: &config.Params{
+					GithubOrganization: "org",
+					BaseBranch:         "develop",
+					HeadBranchPrefixes: []string{"master"},
+					BaseBranchMode:     config.BaseModeFixed,
+					Escalations:        escalations,
+				},
+				API:      &github.APIService{BaseURL: server.URL, Token: "token", Client: server.Client()},
+				Msg:      &notification.SlackService{Client: server.Client()},
+				Wg:       &sync.WaitGroup{},
+				State:    store,
+				ReadOnly: tt.readOnly,
+			}
+
+			if got := bot.GenerateReports(); !cmp.Equal(got, tt.want) {
+				t.Errorf("Unexpected test result for GenerateReports %s", cmp.Diff(tt.want, got))
+			}
+
+			saved, _ := store.Load()
+			if recorded, _ := saved.Get(state.Key("org", "test", "master", "develop")); tt.readOnly && recorded.EscalationLevel != 0 {
+				t.Errorf("Unexpected escalation level saved by a read only run, got = %d", recorded.EscalationLevel)
+			}
+
+			if len(issues) != tt.issues {
+				t.Fatalf("Unexpected number of issues opened want = %d, got = %d", tt.issues, len(issues))
+			}
+			if tt.issues > 0 && (issues[0].Title != "Branch drift: master ahead of develop" || !cmp.Equal(issues[0].Labels, github.Labels{"branch-drift"})) {
+				t.Errorf("Unexpected escalation issue %+v", issues[0])
+			}
+
+			// escalations only fire once per level so the next run only sends the summary, along with the issues that could not be opened
+			if got := bot.GenerateReports(); len(got) != 1 || strings.Contains(got[0].Text, "cc @octocat") {
+				t.Errorf("Unexpected escalations on the next run %+v", got)
+			}
+			if len(issues) != tt.issues+tt.retried {
+				t.Errorf("Unexpected issues opened on the next run want = %d, got = %d", tt.issues+tt.retried, len(issues))
+			}
+		})
+	}
+}
+
+func TestBranchService_filterRepositories(t *testing.T) {
+	repositories := []github.Response{
+		{Name: "api", Topics: []string{"service"}, Visibility: "private", PushedAt: time.Now()},
+		{Name: "disabled", Disabled: true, PushedAt: time.Now()},
+		{Name: "archived", Archived: true, PushedAt: time.Now()},
+		{Name: "fork", Fork: true, Visibility: "public", PushedAt: time.Now()},
+		{Name: "template", IsTemplate: true, PushedAt: time.Now()},
+		{Name: "sandbox-api", Topics: []string{"service"}, Visibility: "internal", PushedAt: time.Now()},
+		{Name: "legacy", Topics: []string{"library"}, Visibility: "private", PushedAt: time.Now().AddDate(0, 0, -120)},
+	}
+
+	tests := []struct {
+		name   string
+		filter config.RepoFilter
+		want   []string
+	}{
+		{
+			name: "Test default filter path",
+			want: []string{"api", "fork", "template", "sandbox-api", "legacy"},
+		},
+		{
+			name:   "Test include archived path",
+			filter: config.RepoFilter{IncludeArchived: true},
+			want:   []string{"api", "archived", "fork", "template", "sandbox-api", "legacy"},
+		},
+		{
+			name:   "Test skip forks and templates path",
+			filter: config.RepoFilter{SkipForks: true, SkipTemplates: true},
+			want:   []string{"api", "sandbox-api", "legacy"},
+		},
+		{
+			name:   "Test topics path",
+			filter: config.RepoFilter{Topics: []string{"service"}},
+			want:   []string{"api", "sandbox-api"},
+		},
+		{
+			name:   "Test visibility path",
+			filter: config.RepoFilter{Visibility: []string{"private", "internal"}},
+			want:   []string{"api", "sandbox-api", "legacy"},
+		},
+		{
+			name:   "Test names path",
+			filter: config.RepoFilter{Names: []string{"*", "!sandbox-*"}},
+			want:   []string{"api", "fork", "template", "legacy"},
+		},
+		{
+			name:   "Test pushed within days path",
+			filter: config.RepoFilter{PushedWithinDays: 90},
+			want:   []string{"api", "fork", "template", "sandbox-api"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot := &BranchService{Params: &config.Params{RepoFilter: tt.filter}}
+
+			var got []string
+			for _, repo := range bot.filterRepositories("org", repositories) {
+				got = append(got, repo.Name)
+			}
+
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("BranchService.filterRepositories() = %v, want %v", got, tt.want)
+			}
 		})
 	}
 }
@@ -114,3 +1290,12 @@ func readTestResource(path string) []byte {
 
 	return content
 }
+
+func daysSince(timestamp string) int {
+	created, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		panic(err)
+	}
+
+	return int(time.Since(created).Hours() / 24)
+}
@@ -0,0 +1,127 @@
+package state
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+const (
+	// dynamoDBKey is the name of the partition key of the table
+	dynamoDBKey = "id"
+	// dynamoDBRunKey is the key of the item that records when the snapshot was taken
+	dynamoDBRunKey = "#run"
+)
+
+// DynamoDBStore keeps the snapshot in a dynamodb table with one item per branch
+// the table must have a string partition key named id
+type DynamoDBStore struct {
+	Table string
+	// Client is created from the default aws session when it is nil
+	Client dynamodbiface.DynamoDBAPI
+}
+
+// dynamoDBItem is the item stored for each branch
+type dynamoDBItem struct {
+	Key string `json:"id"`
+	BranchState
+	RunAt time.Time `json:"run_at,omitempty"`
+}
+
+// Load scans the table for the branches of the last run
+func (d *DynamoDBStore) Load() (*Snapshot, error) {
+	client, err := d.client()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := emptySnapshot()
+
+	var items []map[string]*dynamodb.AttributeValue
+	err = client.ScanPages(&dynamodb.ScanInput{TableName: aws.String(d.Table)}, func(page *dynamodb.ScanOutput, last bool) bool {
+		items = append(items, page.Items...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, attributes := range items {
+		item := dynamoDBItem{}
+		if err := dynamodbattribute.UnmarshalMap(attributes, &item); err != nil {
+			return nil, err
+		}
+
+		if item.Key == dynamoDBRunKey {
+			snapshot.RunAt = item.RunAt
+			continue
+		}
+
+		snapshot.Branches[item.Key] = item.BranchState
+	}
+
+	return snapshot, nil
+}
+
+// Save writes an item for every branch of the snapshot and deletes the items of branches that are no longer ahead
+func (d *DynamoDBStore) Save(snapshot *Snapshot) error {
+	client, err := d.client()
+	if err != nil {
+		return err
+	}
+
+	previous, err := d.Load()
+	if err != nil {
+		return err
+	}
+
+	for key := range previous.Branches {
+		if _, ok := snapshot.Branches[key]; ok {
+			continue
+		}
+
+		_, err := client.DeleteItem(&dynamodb.DeleteItemInput{
+			TableName: aws.String(d.Table),
+			Key:       map[string]*dynamodb.AttributeValue{dynamoDBKey: {S: aws.String(key)}},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	items := []dynamoDBItem{{Key: dynamoDBRunKey, RunAt: snapshot.RunAt}}
+	for key, branch := range snapshot.Branches {
+		items = append(items, dynamoDBItem{Key: key, BranchState: branch})
+	}
+
+	for _, item := range items {
+		attributes, err := dynamodbattribute.MarshalMap(item)
+		if err != nil {
+			return err
+		}
+
+		if _, err := client.PutItem(&dynamodb.PutItemInput{TableName: aws.String(d.Table), Item: attributes}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *DynamoDBStore) client() (dynamodbiface.DynamoDBAPI, error) {
+	if d.Client != nil {
+		return d.Client, nil
+	}
+
+	sess, err := session.NewSession()
+	if err != nil {
+		return nil, err
+	}
+
+	d.Client = dynamodb.New(sess)
+	return d.Client, nil
+}
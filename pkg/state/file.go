@@ -0,0 +1,66 @@
+package state
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// FileStore keeps the snapshot in a local json file
+type FileStore struct {
+	Path string
+}
+
+// Load reads the snapshot from the file, it is empty when the file does not exist yet
+func (f *FileStore) Load() (*Snapshot, error) {
+	data, err := ioutil.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return emptySnapshot(), nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeSnapshot(data)
+}
+
+// Save writes the snapshot to a temporary file that then replaces the file, so a failed run never leaves half a snapshot behind
+func (f *FileStore) Save(snapshot *Snapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(f.Path), filepath.Base(f.Path)+".*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), f.Path)
+}
+
+func decodeSnapshot(data []byte) (*Snapshot, error) {
+	snapshot := emptySnapshot()
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, err
+	}
+
+	if snapshot.Branches == nil {
+		snapshot.Branches = make(map[string]BranchState)
+	}
+
+	return snapshot, nil
+}
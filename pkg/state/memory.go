@@ -0,0 +1,39 @@
+package state
+
+import "sync"
+
+// MemoryStore keeps the snapshot in memory, it is intended for tests and local runs
+type MemoryStore struct {
+	mu       sync.Mutex
+	snapshot *Snapshot
+}
+
+// Load returns a copy of the saved snapshot
+func (m *MemoryStore) Load() (*Snapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.snapshot == nil {
+		return emptySnapshot(), nil
+	}
+
+	return copySnapshot(m.snapshot), nil
+}
+
+// Save keeps a copy of the supplied snapshot
+func (m *MemoryStore) Save(snapshot *Snapshot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.snapshot = copySnapshot(snapshot)
+	return nil
+}
+
+func copySnapshot(snapshot *Snapshot) *Snapshot {
+	copied := &Snapshot{RunAt: snapshot.RunAt, Branches: make(map[string]BranchState, len(snapshot.Branches))}
+	for key, branch := range snapshot.Branches {
+		copied.Branches[key] = branch
+	}
+
+	return copied
+}
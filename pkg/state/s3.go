@@ -0,0 +1,83 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/aaron-vaz/golang-utils/pkg/ioutils"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// S3Store keeps the snapshot in a json object in s3
+type S3Store struct {
+	Bucket string
+	Key    string
+	// Client is created from the default aws session when it is nil
+	Client s3iface.S3API
+}
+
+// Load reads the snapshot from the object, it is empty when the object does not exist yet
+func (s *S3Store) Load() (*Snapshot, error) {
+	client, err := s.client()
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := client.GetObject(&s3.GetObjectInput{Bucket: aws.String(s.Bucket), Key: aws.String(s.Key)})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+		return emptySnapshot(), nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer ioutils.Close(output.Body)
+
+	data, err := ioutil.ReadAll(output.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeSnapshot(data)
+}
+
+// Save replaces the object with the snapshot
+func (s *S3Store) Save(snapshot *Snapshot) error {
+	client, err := s.client()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.PutObject(&s3.PutObjectInput{
+		Bucket:      aws.String(s.Bucket),
+		Key:         aws.String(s.Key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	return err
+}
+
+func (s *S3Store) client() (s3iface.S3API, error) {
+	if s.Client != nil {
+		return s.Client, nil
+	}
+
+	sess, err := session.NewSession()
+	if err != nil {
+		return nil, err
+	}
+
+	s.Client = s3.New(sess)
+	return s.Client, nil
+}
@@ -0,0 +1,222 @@
+package state
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store schemes, a store location is written as scheme:location
+const (
+	// SchemeMemory keeps the state in memory, it is lost when the process exits
+	SchemeMemory = "memory"
+	// SchemeFile keeps the state in a local json file, written as file:/path/to/state.json
+	SchemeFile = "file"
+	// SchemeS3 keeps the state in a json object in s3, written as s3://bucket/key
+	SchemeS3 = "s3"
+	// SchemeDynamoDB keeps the state in a dynamodb table with a string partition key named id, written as dynamodb:table
+	SchemeDynamoDB = "dynamodb"
+)
+
+// BranchState is what is known about a branch that is ahead of its base branch
+type BranchState struct {
+	Owner  string `json:"owner"`
+	Repo   string `json:"repo"`
+	Branch string `json:"branch"`
+	Base   string `json:"base"`
+	Ahead  int    `json:"ahead"`
+	Behind int    `json:"behind"`
+	// FirstSeen is when the branch was first seen ahead of its base, it is kept for as long as the branch stays ahead
+	FirstSeen time.Time `json:"first_seen"`
+	// LastSeen is when the branch was last seen ahead of its base
+	LastSeen time.Time `json:"last_seen"`
+	// EscalationLevel is the highest escalation that has fired for the branch, it is kept for as long as the branch stays ahead
+	EscalationLevel int `json:"escalation_level"`
+}
+
+// Key identifies the branch in a snapshot, a branch compared against several bases has a state for each of them
+func (b BranchState) Key() string {
+	return Key(b.Owner, b.Repo, b.Branch, b.Base)
+}
+
+// DaysAhead returns the number of calendar days the branch has been ahead of its base
+// days are counted in UTC so a run starting a little earlier in the day than the first one still counts the whole day
+func (b BranchState) DaysAhead() int {
+	return int(day(b.LastSeen).Sub(day(b.FirstSeen)).Hours() / 24)
+}
+
+// day returns the start of the UTC day of the supplied time
+func day(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Key returns the key of the supplied branch compared against the supplied base in a snapshot
+func Key(owner, repo, branch, base string) string {
+	return owner + "/" + repo + "/" + base + "..." + branch
+}
+
+// Snapshot is the state of the branches that were ahead in a run
+type Snapshot struct {
+	RunAt    time.Time              `json:"run_at"`
+	Branches map[string]BranchState `json:"branches"`
+
+	mu       sync.Mutex
+	previous *Snapshot
+	// checked are the repos checked in this run, written as owner/repo
+	checked map[string]bool
+}
+
+// NewSnapshot starts the snapshot of a run, the first seen time of branches that were already ahead in the previous run is kept
+func NewSnapshot(previous *Snapshot, now time.Time) *Snapshot {
+	if previous != nil {
+		// snapshots saved before the base was part of the key are keyed again, so their branches are still found
+		branches := make(map[string]BranchState, len(previous.Branches))
+		for _, branch := range previous.Branches {
+			branches[branch.Key()] = branch
+		}
+		previous.Branches = branches
+	}
+
+	return &Snapshot{RunAt: now, Branches: make(map[string]BranchState), previous: previous}
+}
+
+// Record adds a branch that is ahead to the snapshot and returns its state in the previous run, ok is false when it was not ahead then
+// it is safe to call from multiple goroutines
+func (s *Snapshot) Record(branch BranchState) (previous BranchState, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	branch.FirstSeen, branch.LastSeen = s.RunAt, s.RunAt
+	if s.previous != nil {
+		if previous, ok = s.previous.Branches[branch.Key()]; ok && !previous.FirstSeen.IsZero() {
+			branch.FirstSeen = previous.FirstSeen
+		}
+		branch.EscalationLevel = previous.EscalationLevel
+	}
+
+	if s.Branches == nil {
+		s.Branches = make(map[string]BranchState)
+	}
+	s.Branches[branch.Key()] = branch
+
+	return previous, ok
+}
+
+// Get returns the state recorded in this run for the branch with the supplied key
+func (s *Snapshot) Get(key string) (BranchState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	branch, ok := s.Branches[key]
+	return branch, ok
+}
+
+// Escalate records the escalation level reached by the branch with the supplied key in this run
+func (s *Snapshot) Escalate(key string, level int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if branch, ok := s.Branches[key]; ok {
+		branch.EscalationLevel = level
+		s.Branches[key] = branch
+	}
+}
+
+// Check marks the supplied repo as checked in this run, it is safe to call from multiple goroutines
+func (s *Snapshot) Check(owner, repo string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.checked == nil {
+		s.checked = make(map[string]bool)
+	}
+	s.checked[owner+"/"+repo] = true
+}
+
+// Resolved returns the branches of the supplied repo that were ahead in the previous run but have not been recorded in this run
+func (s *Snapshot) Resolved(owner, repo string) []BranchState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.previous == nil {
+		return nil
+	}
+
+	var resolved []BranchState
+	for key, branch := range s.previous.Branches {
+		if _, ok := s.Branches[key]; !ok && branch.Owner == owner && branch.Repo == repo {
+			resolved = append(resolved, branch)
+		}
+	}
+
+	sort.Slice(resolved, func(i, j int) bool { return resolved[i].Branch < resolved[j].Branch })
+	return resolved
+}
+
+// Complete keeps the branches of the repos that were not checked in this run, such as when only some repos are listed
+// so they are still known on the next run
+func (s *Snapshot) Complete() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.previous == nil {
+		return
+	}
+
+	for key, branch := range s.previous.Branches {
+		if _, ok := s.Branches[key]; !ok && !s.checked[branch.Owner+"/"+branch.Repo] {
+			s.Branches[key] = branch
+		}
+	}
+}
+
+// Store loads and saves the snapshot of the last run
+type Store interface {
+	// Load returns the snapshot of the last run, it is empty when nothing has been saved yet
+	Load() (*Snapshot, error)
+	// Save replaces the stored snapshot with the supplied one
+	Save(snapshot *Snapshot) error
+}
+
+// Open returns the store for the supplied location
+// locations are written as memory, file:/path/to/state.json, s3://bucket/key or dynamodb:table
+func Open(location string) (Store, error) {
+	scheme, path := location, ""
+	if i := strings.Index(location, ":"); i >= 0 {
+		scheme, path = location[:i], location[i+1:]
+	}
+
+	switch scheme {
+	case SchemeMemory:
+		return &MemoryStore{}, nil
+
+	case SchemeFile:
+		if path == "" {
+			return nil, fmt.Errorf("file state stores must be of the form file:/path/to/state.json")
+		}
+		return &FileStore{Path: path}, nil
+
+	case SchemeS3:
+		parts := strings.SplitN(strings.TrimPrefix(path, "//"), "/", 2)
+		if !strings.HasPrefix(path, "//") || len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("s3 state stores must be of the form s3://bucket/key")
+		}
+		return &S3Store{Bucket: parts[0], Key: parts[1]}, nil
+
+	case SchemeDynamoDB:
+		if path == "" {
+			return nil, fmt.Errorf("dynamodb state stores must be of the form dynamodb:table")
+		}
+		return &DynamoDBStore{Table: path}, nil
+	}
+
+	return nil, fmt.Errorf("unknown state store %q, use one of %s, %s, %s or %s", location, SchemeMemory, SchemeFile, SchemeS3, SchemeDynamoDB)
+}
+
+// emptySnapshot is returned by stores that have nothing saved yet
+func emptySnapshot() *Snapshot {
+	return &Snapshot{Branches: make(map[string]BranchState)}
+}
@@ -0,0 +1,281 @@
+package state
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+var (
+	yesterday = time.Date(2021, 11, 1, 9, 0, 0, 0, time.UTC)
+	today     = yesterday.Add(24 * time.Hour)
+)
+
+func TestSnapshot_Record(t *testing.T) {
+	previous := NewSnapshot(nil, yesterday)
+	previous.Record(BranchState{Owner: "org", Repo: "api", Branch: "master", Base: "develop", Ahead: 2})
+	previous.Escalate(Key("org", "api", "master", "develop"), 1)
+
+	snapshot := NewSnapshot(previous, today)
+
+	tests := []struct {
+		name      string
+		branch    BranchState
+		wantOK    bool
+		wantAhead int
+		wantDays  int
+		wantLevel int
+	}{
+		{
+			name:      "Test branch still ahead path",
+			branch:    BranchState{Owner: "org", Repo: "api", Branch: "master", Base: "develop", Ahead: 5},
+			wantOK:    true,
+			wantAhead: 2,
+			wantDays:  1,
+			wantLevel: 1,
+		},
+		{
+			name:   "Test newly ahead branch path",
+			branch: BranchState{Owner: "org", Repo: "web", Branch: "master", Base: "develop", Ahead: 1},
+		},
+		{
+			name:   "Test branch ahead of another base path",
+			branch: BranchState{Owner: "org", Repo: "api", Branch: "master", Base: "main", Ahead: 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := snapshot.Record(tt.branch)
+			if ok != tt.wantOK || got.Ahead != tt.wantAhead {
+				t.Errorf("Snapshot.Record() = %v, %t, want ahead %d, %t", got, ok, tt.wantAhead, tt.wantOK)
+			}
+
+			recorded, _ := snapshot.Get(tt.branch.Key())
+			if recorded.Ahead != tt.branch.Ahead || !recorded.LastSeen.Equal(today) || recorded.DaysAhead() != tt.wantDays || recorded.EscalationLevel != tt.wantLevel {
+				t.Errorf("Snapshot.Record() recorded %+v, want ahead %d for %d days at escalation level %d", recorded, tt.branch.Ahead, tt.wantDays, tt.wantLevel)
+			}
+		})
+	}
+}
+
+func TestSnapshot_Complete(t *testing.T) {
+	previous := NewSnapshot(nil, yesterday)
+	previous.Record(BranchState{Owner: "org", Repo: "api", Branch: "master", Ahead: 2})
+	previous.Record(BranchState{Owner: "org", Repo: "web", Branch: "master", Ahead: 1})
+	previous.Record(BranchState{Owner: "org", Repo: "docs", Branch: "master", Ahead: 1})
+
+	snapshot := NewSnapshot(previous, today)
+	snapshot.Check("org", "api")
+	snapshot.Check("org", "web")
+	snapshot.Record(BranchState{Owner: "org", Repo: "api", Branch: "master", Ahead: 3})
+	snapshot.Complete()
+
+	var got []string
+	for key := range snapshot.Branches {
+		got = append(got, key)
+	}
+	sort.Strings(got)
+
+	// web is up to date so it is dropped, docs was not checked so it is kept
+	if want := []string{Key("org", "api", "master", ""), Key("org", "docs", "master", "")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Snapshot.Complete() branches = %v, want %v", got, want)
+	}
+
+	if resolved := snapshot.Resolved("org", "web"); len(resolved) != 1 || resolved[0].Branch != "master" {
+		t.Errorf("Snapshot.Resolved() = %v, want the web master branch", resolved)
+	}
+
+	if resolved := snapshot.Resolved("org", "api"); len(resolved) != 0 {
+		t.Errorf("Snapshot.Resolved() = %v, want no branches for a repo that is still ahead", resolved)
+	}
+
+	if ahead := snapshot.Branches[Key("org", "api", "master", "")].Ahead; ahead != 3 {
+		t.Errorf("Snapshot.Complete() replaced the branch recorded in this run, ahead = %d", ahead)
+	}
+}
+
+func TestBranchState_DaysAhead(t *testing.T) {
+	tests := []struct {
+		name      string
+		firstSeen time.Time
+		lastSeen  time.Time
+		want      int
+	}{
+		{name: "Test same day path", firstSeen: yesterday, lastSeen: yesterday.Add(time.Hour), want: 0},
+		{name: "Test whole days path", firstSeen: yesterday, lastSeen: yesterday.Add(3 * 24 * time.Hour), want: 3},
+		{name: "Test earlier in the day path", firstSeen: yesterday, lastSeen: yesterday.Add(3*24*time.Hour - 5*time.Second), want: 3},
+		{name: "Test past midnight path", firstSeen: time.Date(2021, 11, 1, 23, 50, 0, 0, time.UTC), lastSeen: time.Date(2021, 11, 2, 0, 10, 0, 0, time.UTC), want: 1},
+		{name: "Test other time zone path", firstSeen: yesterday.In(time.FixedZone("UTC+10", 10*60*60)), lastSeen: today, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			branch := BranchState{FirstSeen: tt.firstSeen, LastSeen: tt.lastSeen}
+			if got := branch.DaysAhead(); got != tt.want {
+				t.Errorf("BranchState.DaysAhead() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewSnapshot_LegacyKeys(t *testing.T) {
+	// snapshots saved before the base was part of the key are keyed by owner/repo/branch
+	previous := &Snapshot{RunAt: yesterday, Branches: map[string]BranchState{
+		"org/api/master": {Owner: "org", Repo: "api", Branch: "master", Base: "develop", Ahead: 2, FirstSeen: yesterday, EscalationLevel: 1},
+	}}
+
+	snapshot := NewSnapshot(previous, today)
+	got, ok := snapshot.Record(BranchState{Owner: "org", Repo: "api", Branch: "master", Base: "develop", Ahead: 3})
+	if !ok || got.Ahead != 2 {
+		t.Errorf("Snapshot.Record() = %v, %t, want the branch saved under the legacy key", got, ok)
+	}
+
+	if recorded, _ := snapshot.Get(Key("org", "api", "master", "develop")); !recorded.FirstSeen.Equal(yesterday) || recorded.EscalationLevel != 1 {
+		t.Errorf("Snapshot.Record() recorded %+v, want the first seen time and escalation level to be kept", recorded)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	tests := []struct {
+		name     string
+		location string
+		want     Store
+		wantErr  bool
+	}{
+		{name: "Test memory path", location: "memory", want: &MemoryStore{}},
+		{name: "Test file path", location: "file:/tmp/state.json", want: &FileStore{Path: "/tmp/state.json"}},
+		{name: "Test s3 path", location: "s3://bucket/branch-bot/state.json", want: &S3Store{Bucket: "bucket", Key: "branch-bot/state.json"}},
+		{name: "Test dynamodb path", location: "dynamodb:branch-bot-state", want: &DynamoDBStore{Table: "branch-bot-state"}},
+		{name: "Test invalid s3 path", location: "s3://bucket", wantErr: true},
+		{name: "Test missing file path", location: "file:", wantErr: true},
+		{name: "Test unknown store path", location: "redis://localhost", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Open(tt.location)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Open() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Open() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStores(t *testing.T) {
+	tests := []struct {
+		name  string
+		store Store
+	}{
+		{name: "Test memory store path", store: &MemoryStore{}},
+		{name: "Test file store path", store: &FileStore{Path: filepath.Join(t.TempDir(), "state.json")}},
+		{name: "Test s3 store path", store: &S3Store{Bucket: "bucket", Key: "state.json", Client: &fakeS3{}}},
+		{name: "Test dynamodb store path", store: &DynamoDBStore{Table: "state", Client: &fakeDynamoDB{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			empty, err := tt.store.Load()
+			if err != nil || len(empty.Branches) != 0 {
+				t.Fatalf("Store.Load() = %v, %v, want an empty snapshot before anything is saved", empty, err)
+			}
+
+			first := NewSnapshot(empty, yesterday)
+			first.Record(BranchState{Owner: "org", Repo: "api", Branch: "master", Base: "develop", Ahead: 2, Behind: 1})
+			first.Record(BranchState{Owner: "org", Repo: "web", Branch: "master", Base: "develop", Ahead: 1})
+			if err := tt.store.Save(first); err != nil {
+				t.Fatalf("Store.Save() unexpected error = %v", err)
+			}
+
+			loaded, err := tt.store.Load()
+			if err != nil {
+				t.Fatalf("Store.Load() unexpected error = %v", err)
+			}
+
+			second := NewSnapshot(loaded, today)
+			second.Record(BranchState{Owner: "org", Repo: "api", Branch: "master", Base: "develop", Ahead: 3})
+			if err := tt.store.Save(second); err != nil {
+				t.Fatalf("Store.Save() unexpected error = %v", err)
+			}
+
+			got, err := tt.store.Load()
+			if err != nil {
+				t.Fatalf("Store.Load() unexpected error = %v", err)
+			}
+
+			want := map[string]BranchState{
+				Key("org", "api", "master", "develop"): {Owner: "org", Repo: "api", Branch: "master", Base: "develop", Ahead: 3, FirstSeen: yesterday, LastSeen: today},
+			}
+			if !got.RunAt.Equal(today) || !reflect.DeepEqual(got.Branches, want) {
+				t.Errorf("Store.Load() = %v %+v, want %v %+v", got.RunAt, got.Branches, today, want)
+			}
+		})
+	}
+}
+
+// fakeS3 keeps the objects written to it in memory
+type fakeS3 struct {
+	s3iface.S3API
+	objects map[string][]byte
+}
+
+func (f *fakeS3) GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	data, ok := f.objects[aws.StringValue(input.Bucket)+"/"+aws.StringValue(input.Key)]
+	if !ok {
+		return nil, awserr.New(s3.ErrCodeNoSuchKey, "The specified key does not exist.", nil)
+	}
+	return &s3.GetObjectOutput{Body: ioutil.NopCloser(bytes.NewReader(data))}, nil
+}
+
+func (f *fakeS3) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	data, err := ioutil.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.objects == nil {
+		f.objects = make(map[string][]byte)
+	}
+	f.objects[aws.StringValue(input.Bucket)+"/"+aws.StringValue(input.Key)] = data
+	return &s3.PutObjectOutput{}, nil
+}
+
+// fakeDynamoDB keeps the items written to it in memory, keyed by their id attribute
+type fakeDynamoDB struct {
+	dynamodbiface.DynamoDBAPI
+	items map[string]map[string]*dynamodb.AttributeValue
+}
+
+func (f *fakeDynamoDB) ScanPages(input *dynamodb.ScanInput, fn func(*dynamodb.ScanOutput, bool) bool) error {
+	output := &dynamodb.ScanOutput{}
+	for _, item := range f.items {
+		output.Items = append(output.Items, item)
+	}
+	fn(output, true)
+	return nil
+}
+
+func (f *fakeDynamoDB) PutItem(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	if f.items == nil {
+		f.items = make(map[string]map[string]*dynamodb.AttributeValue)
+	}
+	f.items[aws.StringValue(input.Item[dynamoDBKey].S)] = input.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) DeleteItem(input *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+	delete(f.items, aws.StringValue(input.Key[dynamoDBKey].S))
+	return &dynamodb.DeleteItemOutput{}, nil
+}